@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/gorilla/mux"
+
+	"github.com/storeros/ipos/cmd/ipos/logger"
+	"github.com/storeros/ipos/pkg/bucket/pinning"
+	iampolicy "github.com/storeros/ipos/pkg/iam/policy"
+)
+
+const (
+	maxBucketPinningConfigSize = 1 * humanize.KiByte
+)
+
+func (a adminAPIHandlers) SetBucketPinningHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "SetBucketPinning")
+
+	defer logger.AuditLog(w, r, "SetBucketPinning", mustGetClaimsFromToken(r))
+
+	objectAPI, _ := validateAdminReq(ctx, w, r, iampolicy.SetBucketPinningAdminAction)
+	if objectAPI == nil {
+		return
+	}
+
+	bucket := mux.Vars(r)["bucket"]
+
+	if _, err := objectAPI.GetBucketInfo(ctx, bucket); err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+
+	if r.ContentLength <= 0 {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrMissingContentLength), r.URL)
+		return
+	}
+
+	if r.ContentLength > maxBucketPinningConfigSize {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrEntityTooLarge), r.URL)
+		return
+	}
+
+	config, err := pinning.ParseConfig(io.LimitReader(r.Body, r.ContentLength))
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErrWithErr(ErrAdminConfigBadJSON, err), r.URL)
+		return
+	}
+
+	if config.Remote && (globalPinningService == nil || !globalPinningService.Enabled) {
+		writeErrorResponseJSON(ctx, w, errorCodes.ToAPIErr(ErrPinningServiceNotConfigured), r.URL)
+		return
+	}
+
+	if err = objectAPI.SetBucketPinning(ctx, bucket, config); err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+
+	globalBucketPinningSys.Set(bucket, *config)
+
+	writeSuccessResponseHeadersOnly(w)
+}
+
+func (a adminAPIHandlers) GetBucketPinningHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "GetBucketPinning")
+
+	defer logger.AuditLog(w, r, "GetBucketPinning", mustGetClaimsFromToken(r))
+
+	objectAPI, _ := validateAdminReq(ctx, w, r, iampolicy.GetBucketPinningAdminAction)
+	if objectAPI == nil {
+		return
+	}
+
+	bucket := mux.Vars(r)["bucket"]
+
+	if _, err := objectAPI.GetBucketInfo(ctx, bucket); err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+
+	config, err := objectAPI.GetBucketPinning(ctx, bucket)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+
+	writeSuccessResponseJSON(w, data)
+}
+
+func (a adminAPIHandlers) RemoveBucketPinningHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "RemoveBucketPinning")
+
+	defer logger.AuditLog(w, r, "RemoveBucketPinning", mustGetClaimsFromToken(r))
+
+	objectAPI, _ := validateAdminReq(ctx, w, r, iampolicy.SetBucketPinningAdminAction)
+	if objectAPI == nil {
+		return
+	}
+
+	bucket := mux.Vars(r)["bucket"]
+
+	if _, err := objectAPI.GetBucketInfo(ctx, bucket); err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+
+	if err := objectAPI.DeleteBucketPinning(ctx, bucket); err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+
+	globalBucketPinningSys.Remove(bucket)
+
+	writeSuccessResponseHeadersOnly(w)
+}
+
+func (a adminAPIHandlers) ObjectPinStatusHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "ObjectPinStatus")
+
+	defer logger.AuditLog(w, r, "ObjectPinStatus", mustGetClaimsFromToken(r))
+
+	objectAPI, _ := validateAdminReq(ctx, w, r, iampolicy.GetBucketPinningAdminAction)
+	if objectAPI == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	if err := checkObjectArgs(ctx, bucket, object, objectAPI); err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+
+	pin, err := objectAPI.GetObjectPinInfo(ctx, bucket, object)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+
+	data, err := json.Marshal(pin)
+	if err != nil {
+		writeErrorResponseJSON(ctx, w, toAdminAPIErr(ctx, err), r.URL)
+		return
+	}
+
+	writeSuccessResponseJSON(w, data)
+}
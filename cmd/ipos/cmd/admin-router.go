@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/storeros/ipos/pkg/madmin"
+)
+
+const (
+	adminPathPrefix       = iposReservedBucketPath + "/admin"
+	adminAPIVersion       = madmin.AdminAPIVersion
+	adminAPIVersionPrefix = SlashSeparator + adminAPIVersion
+)
+
+type adminAPIHandlers struct{}
+
+func registerAdminRouter(router *mux.Router) {
+	adminAPI := adminAPIHandlers{}
+	adminRouter := router.PathPrefix(adminPathPrefix).Subrouter()
+
+	adminVersion := adminAPIVersionPrefix
+
+	adminRouter.Methods(http.MethodPost).Path(adminVersion+"/service").HandlerFunc(httpTraceAll(adminAPI.ServiceActionHandler)).Queries("action", "{action:.*}")
+
+	adminRouter.Methods(http.MethodGet).Path(adminVersion + "/info").HandlerFunc(httpTraceAll(adminAPI.ServerInfoHandler))
+	adminRouter.Methods(http.MethodGet).Path(adminVersion + "/storageinfo").HandlerFunc(httpTraceAll(adminAPI.StorageInfoHandler))
+	adminRouter.Methods(http.MethodGet).Path(adminVersion + "/datausageinfo").HandlerFunc(httpTraceAll(adminAPI.DataUsageInfoHandler))
+
+	adminRouter.Methods(http.MethodPut).Path(adminVersion+"/add-canned-policy").HandlerFunc(httpTraceHdrs(adminAPI.AddCannedPolicy)).Queries("name", "{name:.*}")
+	adminRouter.Methods(http.MethodDelete).Path(adminVersion+"/remove-canned-policy").HandlerFunc(httpTraceHdrs(adminAPI.RemoveCannedPolicy)).Queries("name", "{name:.*}")
+	adminRouter.Methods(http.MethodGet).Path(adminVersion+"/info-canned-policy").HandlerFunc(httpTraceHdrs(adminAPI.InfoCannedPolicy)).Queries("name", "{name:.*}")
+	adminRouter.Methods(http.MethodGet).Path(adminVersion + "/list-canned-policies").HandlerFunc(httpTraceHdrs(adminAPI.ListCannedPolicies))
+	adminRouter.Methods(http.MethodPut).Path(adminVersion+"/set-user-or-group-policy").HandlerFunc(httpTraceHdrs(adminAPI.SetPolicyForUserOrGroup)).Queries("policyName", "{policyName:.*}", "userOrGroup", "{userOrGroup:.*}", "isGroup", "{isGroup:true|false}")
+
+	adminRouter.Methods(http.MethodPut).Path(adminVersion+"/add-user").HandlerFunc(httpTraceHdrs(adminAPI.AddUser)).Queries("accessKey", "{accessKey:.*}")
+	adminRouter.Methods(http.MethodDelete).Path(adminVersion+"/remove-user").HandlerFunc(httpTraceHdrs(adminAPI.RemoveUser)).Queries("accessKey", "{accessKey:.*}")
+	adminRouter.Methods(http.MethodGet).Path(adminVersion + "/list-users").HandlerFunc(httpTraceHdrs(adminAPI.ListUsers))
+	adminRouter.Methods(http.MethodGet).Path(adminVersion+"/user-info").HandlerFunc(httpTraceHdrs(adminAPI.GetUserInfo)).Queries("accessKey", "{accessKey:.*}")
+	adminRouter.Methods(http.MethodPut).Path(adminVersion+"/set-user-status").HandlerFunc(httpTraceHdrs(adminAPI.SetUserStatus)).Queries("accessKey", "{accessKey:.*}", "status", "{status:.*}")
+
+	adminRouter.Methods(http.MethodPut).Path(adminVersion + "/add-service-account").HandlerFunc(httpTraceHdrs(adminAPI.AddServiceAccount))
+	adminRouter.Methods(http.MethodGet).Path(adminVersion + "/list-service-accounts").HandlerFunc(httpTraceHdrs(adminAPI.ListServiceAccounts))
+	adminRouter.Methods(http.MethodDelete).Path(adminVersion+"/delete-service-account").HandlerFunc(httpTraceHdrs(adminAPI.DeleteServiceAccount)).Queries("accessKey", "{accessKey:.*}")
+
+	adminRouter.Methods(http.MethodPut).Path(adminVersion + "/update-group-members").HandlerFunc(httpTraceHdrs(adminAPI.UpdateGroupMembers))
+	adminRouter.Methods(http.MethodGet).Path(adminVersion+"/group").HandlerFunc(httpTraceHdrs(adminAPI.GetGroup)).Queries("group", "{group:.*}")
+	adminRouter.Methods(http.MethodGet).Path(adminVersion + "/groups").HandlerFunc(httpTraceHdrs(adminAPI.ListGroups))
+	adminRouter.Methods(http.MethodPut).Path(adminVersion+"/set-group-status").HandlerFunc(httpTraceHdrs(adminAPI.SetGroupStatus)).Queries("group", "{group:.*}", "status", "{status:.*}")
+
+	adminRouter.Methods(http.MethodPut).Path(adminVersion+"/set-bucket-pinning").HandlerFunc(httpTraceHdrs(adminAPI.SetBucketPinningHandler)).Queries("bucket", "{bucket:.*}")
+	adminRouter.Methods(http.MethodGet).Path(adminVersion+"/get-bucket-pinning").HandlerFunc(httpTraceHdrs(adminAPI.GetBucketPinningHandler)).Queries("bucket", "{bucket:.*}")
+	adminRouter.Methods(http.MethodDelete).Path(adminVersion+"/remove-bucket-pinning").HandlerFunc(httpTraceHdrs(adminAPI.RemoveBucketPinningHandler)).Queries("bucket", "{bucket:.*}")
+	adminRouter.Methods(http.MethodGet).Path(adminVersion+"/pin-status").HandlerFunc(httpTraceHdrs(adminAPI.ObjectPinStatusHandler)).Queries("bucket", "{bucket:.*}", "object", "{object:.*}")
+
+	adminRouter.NotFoundHandler = http.HandlerFunc(httpTraceAll(errorResponseHandler))
+}
@@ -7,8 +7,12 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/storeros/ipos/cmd/ipos/crypto"
 	"github.com/storeros/ipos/cmd/ipos/logger"
+	"github.com/storeros/ipos/pkg/auth"
 	objectlock "github.com/storeros/ipos/pkg/bucket/object/lock"
+	"github.com/storeros/ipos/pkg/bucket/object/tagging"
+	"github.com/storeros/ipos/pkg/bucket/policy"
 	"github.com/storeros/ipos/pkg/hash"
 )
 
@@ -36,8 +40,11 @@ const (
 	ErrNone APIErrorCode = iota
 	ErrAccessDenied
 	ErrBadDigest
+	ErrEntityTooSmall
 	ErrEntityTooLarge
 	ErrPolicyTooLarge
+	ErrNoSuchBucketPolicy
+	ErrNoSuchLifecycleConfiguration
 	ErrIncompleteBody
 	ErrInternalError
 	ErrInvalidAccessKeyID
@@ -45,13 +52,23 @@ const (
 	ErrInvalidDigest
 	ErrInvalidRange
 	ErrInvalidMaxKeys
+	ErrInvalidMaxUploads
+	ErrInvalidMaxParts
+	ErrInvalidPartNumberMarker
 	ErrInvalidEncodingMethod
+	ErrInvalidPart
+	ErrInvalidPartOrder
+	ErrInvalidCopyDest
 	ErrInvalidCopySource
+	ErrInvalidMetadataDirective
+	ErrInvalidCopyPartRange
+	ErrInvalidCopyPartRangeSource
 	ErrMalformedXML
 	ErrMissingContentLength
 	ErrMissingContentMD5
 	ErrNoSuchBucket
 	ErrNoSuchKey
+	ErrNoSuchUpload
 	ErrNoSuchVersion
 	ErrNotImplemented
 	ErrPreconditionFailed
@@ -89,6 +106,23 @@ const (
 	ErrObjectLocked
 	ErrSSEEncryptedObject
 	ErrInvalidEncryptionParameters
+	ErrInvalidEncryptionMethod
+	ErrInsecureSSECustomerRequest
+	ErrSSEMultipartEncrypted
+	ErrInvalidSSECustomerAlgorithm
+	ErrInvalidSSECustomerKey
+	ErrMissingSSECustomerKey
+	ErrMissingSSECustomerKeyMD5
+	ErrSSECustomerKeyMD5Mismatch
+	ErrInvalidSSECustomerParameters
+	ErrIncompatibleEncryptionMethod
+	ErrKMSNotConfigured
+	ErrKMSKeyNotFound
+	ErrNoSuchBucketSSEConfig
+	ErrNoSuchBucketPinningConfig
+	ErrPinningServiceNotConfigured
+	ErrInvalidCID
+	ErrImportEncryptionRequired
 
 	ErrNoAccessKey
 	ErrInvalidToken
@@ -101,6 +135,7 @@ const (
 	ErrObjectExistsAsDirectory
 	ErrServerNotInitialized
 	ErrOperationMaxedOut
+	ErrSlowDown
 	ErrInvalidRequest
 	ErrInvalidStorageClass
 	ErrObjectTampered
@@ -108,6 +143,22 @@ const (
 	ErrIncorrectContinuationToken
 
 	ErrInvalidDecompressedSize
+
+	ErrMalformedPOSTRequest
+	ErrPOSTFileRequired
+	ErrPostPolicyConditionInvalidFormat
+	ErrAdminInvalidArgument
+	ErrAdminNoSuchUser
+	ErrAdminNoSuchGroup
+	ErrAdminGroupNotEmpty
+	ErrAdminNoSuchPolicy
+	ErrAdminInvalidAccessKey
+	ErrAdminInvalidSecretKey
+	ErrAdminConfigTooLarge
+	ErrAdminConfigBadJSON
+	ErrAdminAccountNotEligible
+	ErrAdminServiceAccountNotFound
+	ErrAddUserInvalidArgument
 )
 
 type errorCodeMap map[APIErrorCode]APIError
@@ -128,11 +179,21 @@ func (e errorCodeMap) ToAPIErr(errCode APIErrorCode) APIError {
 }
 
 var errorCodes = errorCodeMap{
+	ErrInvalidCopyDest: {
+		Code:           "InvalidRequest",
+		Description:    "This copy request is illegal because it is trying to copy an object to itself without changing the object's metadata, storage class, website redirect location or encryption attributes.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	ErrInvalidCopySource: {
 		Code:           "InvalidArgument",
 		Description:    "Copy Source must mention the source bucket and key: sourcebucket/sourcekey.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrInvalidMetadataDirective: {
+		Code:           "InvalidArgument",
+		Description:    "Unknown metadata directive.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	ErrInvalidStorageClass: {
 		Code:           "InvalidStorageClass",
 		Description:    "Invalid storage class.",
@@ -143,6 +204,21 @@ var errorCodes = errorCodeMap{
 		Description:    "Argument maxKeys must be an integer between 0 and 2147483647",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrInvalidMaxUploads: {
+		Code:           "InvalidArgument",
+		Description:    "Argument max-uploads must be an integer between 0 and 2147483647",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidMaxParts: {
+		Code:           "InvalidArgument",
+		Description:    "Argument max-parts must be an integer between 0 and 2147483647",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidPartNumberMarker: {
+		Code:           "InvalidArgument",
+		Description:    "Argument partNumberMarker must be an integer.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	ErrInvalidEncodingMethod: {
 		Code:           "InvalidArgument",
 		Description:    "Invalid Encoding Method specified in Request",
@@ -153,11 +229,26 @@ var errorCodes = errorCodeMap{
 		Description:    "Access Denied.",
 		HTTPStatusCode: http.StatusForbidden,
 	},
+	ErrEntityTooSmall: {
+		Code:           "EntityTooSmall",
+		Description:    "Your proposed upload is smaller than the minimum allowed object size.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	ErrEntityTooLarge: {
 		Code:           "EntityTooLarge",
 		Description:    "Your proposed upload exceeds the maximum allowed object size.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrNoSuchBucketPolicy: {
+		Code:           "NoSuchBucketPolicy",
+		Description:    "The bucket policy does not exist",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrNoSuchLifecycleConfiguration: {
+		Code:           "NoSuchLifecycleConfiguration",
+		Description:    "The lifecycle configuration does not exist",
+		HTTPStatusCode: http.StatusNotFound,
+	},
 	ErrPolicyTooLarge: {
 		Code:           "PolicyTooLarge",
 		Description:    "Policy exceeds the maximum allowed document size.",
@@ -173,6 +264,11 @@ var errorCodes = errorCodeMap{
 		Description:    "We encountered an internal error, please try again.",
 		HTTPStatusCode: http.StatusInternalServerError,
 	},
+	ErrBadDigest: {
+		Code:           "BadDigest",
+		Description:    "The Content-Md5 you specified did not match what we received.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	ErrInvalidDigest: {
 		Code:           "InvalidDigest",
 		Description:    "The Content-Md5 you specified is not valid.",
@@ -183,6 +279,26 @@ var errorCodes = errorCodeMap{
 		Description:    "The requested range is not satisfiable",
 		HTTPStatusCode: http.StatusRequestedRangeNotSatisfiable,
 	},
+	ErrInvalidCopyPartRange: {
+		Code:           "InvalidArgument",
+		Description:    "The x-amz-copy-source-range value must be of the form bytes=first-last where first and last are the zero-based offsets of the first and last bytes to copy",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidCopyPartRangeSource: {
+		Code:           "InvalidArgument",
+		Description:    "Range specified is not valid for source object",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidPart: {
+		Code:           "InvalidPart",
+		Description:    "One or more of the specified parts could not be found.  The part may not have been uploaded, or the specified entity tag may not match the part's entity tag.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidPartOrder: {
+		Code:           "InvalidPartOrder",
+		Description:    "The list of parts was not in ascending order. The parts list must be specified in order by part number.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	ErrMalformedXML: {
 		Code:           "MalformedXML",
 		Description:    "The XML you provided was not well-formed or did not validate against our published schema.",
@@ -203,6 +319,16 @@ var errorCodes = errorCodeMap{
 		Description:    "The specified bucket does not exist",
 		HTTPStatusCode: http.StatusNotFound,
 	},
+	ErrNoSuchKey: {
+		Code:           "NoSuchKey",
+		Description:    "The specified key does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrNoSuchUpload: {
+		Code:           "NoSuchUpload",
+		Description:    "The specified multipart upload does not exist. The upload ID may be invalid, or the upload may have been aborted or completed.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
 	ErrNoSuchVersion: {
 		Code:           "NoSuchVersion",
 		Description:    "Indicates that the version ID specified in the request does not match an existing version.",
@@ -263,6 +389,96 @@ var errorCodes = errorCodeMap{
 		Description:    "The encryption parameters are not applicable to this object.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrInvalidEncryptionMethod: {
+		Code:           "InvalidRequest",
+		Description:    "The encryption method specified is not supported",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInsecureSSECustomerRequest: {
+		Code:           "InvalidRequest",
+		Description:    "Requests specifying Server Side Encryption with Customer provided keys must be made over a secure connection.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrSSEMultipartEncrypted: {
+		Code:           "InvalidRequest",
+		Description:    "The multipart upload initiate requested encryption. Subsequent part requests must include the appropriate encryption parameters.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidSSECustomerAlgorithm: {
+		Code:           "InvalidArgument",
+		Description:    "Requests specifying Server Side Encryption with Customer provided keys must provide a valid encryption algorithm.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidSSECustomerKey: {
+		Code:           "InvalidArgument",
+		Description:    "The secret key was invalid for the specified algorithm.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrMissingSSECustomerKey: {
+		Code:           "InvalidArgument",
+		Description:    "Requests specifying Server Side Encryption with Customer provided keys must provide an appropriate secret key.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrMissingSSECustomerKeyMD5: {
+		Code:           "InvalidArgument",
+		Description:    "Requests specifying Server Side Encryption with Customer provided keys must provide the client calculated MD5 of the secret key.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrSSECustomerKeyMD5Mismatch: {
+		Code:           "InvalidArgument",
+		Description:    "The calculated MD5 hash of the key did not match the hash that was provided.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidSSECustomerParameters: {
+		Code:           "InvalidArgument",
+		Description:    "The provided encryption parameters did not match the ones used originally.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrIncompatibleEncryptionMethod: {
+		Code:           "InvalidArgument",
+		Description:    "Server side encryption specified with both SSE-C and SSE-S3 headers",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrKMSNotConfigured: {
+		Code:           "InvalidArgument",
+		Description:    "Server side encryption specified but KMS is not configured",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrKMSKeyNotFound: {
+		Code:           "KMS.NotFoundException",
+		Description:    "The specified KMS key ID does not exist",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrNoSuchBucketSSEConfig: {
+		Code:           "ServerSideEncryptionConfigurationNotFoundError",
+		Description:    "The server side encryption configuration was not found",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrNoSuchBucketPinningConfig: {
+		Code:           "XIPOSNoSuchBucketPinningConfig",
+		Description:    "The bucket pinning configuration was not found",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrPinningServiceNotConfigured: {
+		Code:           "XIPOSPinningServiceNotConfigured",
+		Description:    "Remote pinning requires a pinning service endpoint to be configured",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidCID: {
+		Code:           "XIPOSInvalidCID",
+		Description:    "The specified CID is not valid or does not reference a file",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrImportEncryptionRequired: {
+		Code:           "XIPOSImportEncryptionRequired",
+		Description:    "Objects can not be imported into a bucket that requires server side encryption",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrObjectTampered: {
+		Code:           "XIPOSObjectTampered",
+		Description:    errObjectTampered.Error(),
+		HTTPStatusCode: http.StatusPartialContent,
+	},
 	ErrNoAccessKey: {
 		Code:           "AccessDenied",
 		Description:    "No AWSAccessKey was presented",
@@ -279,11 +495,26 @@ var errorCodes = errorCodeMap{
 		Description:    "The provided 'x-amz-content-sha256' header does not match what was computed.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrObjectExistsAsDirectory: {
+		Code:           "XIPOSObjectExistsAsDirectory",
+		Description:    "Object name already exists as a directory.",
+		HTTPStatusCode: http.StatusConflict,
+	},
 	ErrServerNotInitialized: {
 		Code:           "XIPOSServerNotInitialized",
 		Description:    "Server not initialized, please try again.",
 		HTTPStatusCode: http.StatusServiceUnavailable,
 	},
+	ErrOperationMaxedOut: {
+		Code:           "SlowDown",
+		Description:    "A timeout exceeded while waiting to proceed with the request, please reduce your request rate",
+		HTTPStatusCode: http.StatusServiceUnavailable,
+	},
+	ErrSlowDown: {
+		Code:           "SlowDown",
+		Description:    "Please reduce your request rate.",
+		HTTPStatusCode: http.StatusServiceUnavailable,
+	},
 
 	ErrInvalidRequest: {
 		Code:           "InvalidRequest",
@@ -300,6 +531,81 @@ var errorCodes = errorCodeMap{
 		Description:    "The data provided is unfit for decompression",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrMalformedPOSTRequest: {
+		Code:           "MalformedPOSTRequest",
+		Description:    "The body of your POST request is not well-formed multipart/form-data.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrPOSTFileRequired: {
+		Code:           "InvalidArgument",
+		Description:    "POST requires exactly one file upload per request.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrPostPolicyConditionInvalidFormat: {
+		Code:           "PostPolicyInvalidKeyName",
+		Description:    "Invalid according to Policy: Policy Condition failed",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	ErrAdminInvalidArgument: {
+		Code:           "XIPOSAdminInvalidArgument",
+		Description:    "Invalid arguments specified.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminNoSuchUser: {
+		Code:           "XIPOSAdminNoSuchUser",
+		Description:    "The specified user does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrAdminNoSuchGroup: {
+		Code:           "XIPOSAdminNoSuchGroup",
+		Description:    "The specified group does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrAdminGroupNotEmpty: {
+		Code:           "XIPOSAdminGroupNotEmpty",
+		Description:    "The specified group is not empty - cannot remove it.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminNoSuchPolicy: {
+		Code:           "XIPOSAdminNoSuchPolicy",
+		Description:    "The canned policy does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrAdminInvalidAccessKey: {
+		Code:           "XIPOSAdminInvalidAccessKey",
+		Description:    "The access key is invalid.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminInvalidSecretKey: {
+		Code:           "XIPOSAdminInvalidSecretKey",
+		Description:    "The secret key is invalid.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminConfigTooLarge: {
+		Code:           "XIPOSAdminConfigTooLarge",
+		Description:    fmt.Sprintf("Configuration data provided exceeds the allowed maximum of %d bytes", maxEConfigJSONSize),
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminConfigBadJSON: {
+		Code:           "XIPOSAdminConfigBadJSON",
+		Description:    "JSON configuration provided is of incorrect format",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminAccountNotEligible: {
+		Code:           "XIPOSInvalidIAMCredentials",
+		Description:    "The administrator key is not eligible for this operation",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	ErrAdminServiceAccountNotFound: {
+		Code:           "XIPOSInvalidIAMCredentials",
+		Description:    "The specified service account is not found",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrAddUserInvalidArgument: {
+		Code:           "XIPOSInvalidIAMCredentials",
+		Description:    "User is not allowed to be same as admin access key",
+		HTTPStatusCode: http.StatusForbidden,
+	},
 }
 
 func toAPIErrorCode(ctx context.Context, err error) (apiErr APIErrorCode) {
@@ -309,22 +615,101 @@ func toAPIErrorCode(ctx context.Context, err error) (apiErr APIErrorCode) {
 	switch err {
 	case errAuthentication:
 		apiErr = ErrAccessDenied
+	case errInvalidRange:
+		apiErr = ErrInvalidRange
 	case errInvalidEncryptionParameters:
 		apiErr = ErrInvalidEncryptionParameters
 	case errEncryptedObject:
 		apiErr = ErrSSEEncryptedObject
+	case errObjectTampered:
+		apiErr = ErrObjectTampered
+	case errInvalidSSEParameters:
+		apiErr = ErrInvalidSSECustomerParameters
+	case errKMSNotConfigured:
+		apiErr = ErrKMSNotConfigured
+	case errKMSKeyNotFound:
+		apiErr = ErrKMSKeyNotFound
+	case crypto.ErrInvalidEncryptionMethod:
+		apiErr = ErrInvalidEncryptionMethod
+	case crypto.ErrInvalidCustomerAlgorithm:
+		apiErr = ErrInvalidSSECustomerAlgorithm
+	case crypto.ErrMissingCustomerKey:
+		apiErr = ErrMissingSSECustomerKey
+	case crypto.ErrMissingCustomerKeyMD5:
+		apiErr = ErrMissingSSECustomerKeyMD5
+	case crypto.ErrCustomerKeyMD5Mismatch:
+		apiErr = ErrSSECustomerKeyMD5Mismatch
+	case crypto.ErrInvalidCustomerKey, crypto.ErrSecretKeyMismatch:
+		apiErr = ErrAccessDenied
+	case crypto.ErrIncompatibleEncryptionMethod:
+		apiErr = ErrIncompatibleEncryptionMethod
 	case objectlock.ErrMalformedXML:
 		apiErr = ErrMalformedXML
+	case errInvalidArgument:
+		apiErr = ErrAdminInvalidArgument
+	case errNoSuchUser:
+		apiErr = ErrAdminNoSuchUser
+	case errNoSuchGroup:
+		apiErr = ErrAdminNoSuchGroup
+	case errGroupNotEmpty:
+		apiErr = ErrAdminGroupNotEmpty
+	case errNoSuchPolicy:
+		apiErr = ErrAdminNoSuchPolicy
+	case errNoSuchServiceAccount:
+		apiErr = ErrAdminServiceAccountNotFound
+	case auth.ErrInvalidAccessKeyLength:
+		apiErr = ErrAdminInvalidAccessKey
+	case auth.ErrInvalidSecretKeyLength:
+		apiErr = ErrAdminInvalidSecretKey
+	}
+	if apiErr != ErrNone {
+		return apiErr
 	}
 	switch err.(type) {
+	case SlowDown:
+		apiErr = ErrSlowDown
 	case IncompleteBody:
 		apiErr = ErrIncompleteBody
+	case ObjectTooLarge:
+		apiErr = ErrEntityTooLarge
 	case PrefixAccessDenied:
 		apiErr = ErrAccessDenied
 	case BucketNotFound:
 		apiErr = ErrNoSuchBucket
+	case BucketPolicyNotFound:
+		apiErr = ErrNoSuchBucketPolicy
+	case BucketLifecycleNotFound:
+		apiErr = ErrNoSuchLifecycleConfiguration
+	case BucketSSEConfigNotFound:
+		apiErr = ErrNoSuchBucketSSEConfig
+	case BucketPinningConfigNotFound:
+		apiErr = ErrNoSuchBucketPinningConfig
+	case ObjectNotFound:
+		apiErr = ErrNoSuchKey
+	case ObjectExistsAsDirectory:
+		apiErr = ErrObjectExistsAsDirectory
+	case InvalidRange:
+		apiErr = ErrInvalidRange
+	case PreConditionFailed:
+		apiErr = ErrPreconditionFailed
 	case hash.SHA256Mismatch:
 		apiErr = ErrContentSHA256Mismatch
+	case hash.BadDigest:
+		apiErr = ErrBadDigest
+	case InvalidUploadID:
+		apiErr = ErrNoSuchUpload
+	case InvalidCID:
+		apiErr = ErrInvalidCID
+	case MalformedUploadID:
+		apiErr = ErrNoSuchUpload
+	case InvalidPart:
+		apiErr = ErrInvalidPart
+	case PartTooSmall:
+		apiErr = ErrEntityTooSmall
+	case InvalidUploadIDKeyCombination:
+		apiErr = ErrNotImplemented
+	case InvalidMarkerPrefixCombination:
+		apiErr = ErrNotImplemented
 	case NotImplemented:
 		apiErr = ErrNotImplemented
 	default:
@@ -354,6 +739,20 @@ func toAPIError(ctx context.Context, err error) APIError {
 
 	var apiErr = errorCodes.ToAPIErr(toAPIErrorCode(ctx, err))
 	if apiErr.Code == "InternalError" {
+		switch e := err.(type) {
+		case policy.Error:
+			apiErr = APIError{
+				Code:           "MalformedPolicy",
+				Description:    e.Error(),
+				HTTPStatusCode: http.StatusBadRequest,
+			}
+		case tagging.Error:
+			apiErr = APIError{
+				Code:           e.Code(),
+				Description:    e.Error(),
+				HTTPStatusCode: http.StatusBadRequest,
+			}
+		}
 	}
 
 	return apiErr
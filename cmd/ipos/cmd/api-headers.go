@@ -72,6 +72,14 @@ func setObjectHeaders(w http.ResponseWriter, objInfo ObjectInfo, rs *HTTPRangeSp
 		w.Header().Set(xhttp.AmzTagCount, strconv.Itoa(tagCount))
 	}
 
+	if objInfo.CID != "" {
+		w.Header().Set(xhttp.IPOSCID, objInfo.CID)
+	}
+
+	if pinStatus := objInfo.Pin.String(); pinStatus != "" {
+		w.Header().Set(xhttp.IPOSPinStatus, pinStatus)
+	}
+
 	for k, v := range objInfo.UserDefined {
 		if HasPrefix(k, ReservedMetadataPrefix) {
 			continue
@@ -290,8 +290,7 @@ func getObjectLocation(r *http.Request, domains []string, bucket, object string)
 		Scheme: proto,
 	}
 	for _, domain := range domains {
-		if strings.Contains(r.Host, domain) {
-			u.Host = bucket + "." + r.Host
+		if strings.HasPrefix(r.Host, bucket+"."+domain) {
 			u.Path = path.Join(SlashSeparator, object)
 			break
 		}
@@ -449,6 +448,9 @@ func generateListObjectsV2Response(bucket, prefix, token, nextToken, startAfter,
 				}
 				content.UserMetadata[k] = v
 			}
+			if object.CID != "" {
+				content.UserMetadata[xhttp.IPOSCID] = object.CID
+			}
 		}
 		contents = append(contents, content)
 	}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"net/http"
+	"path"
+	"sync"
+
+	"github.com/storeros/ipos/cmd/ipos/crypto"
+	bucketsse "github.com/storeros/ipos/pkg/bucket/encryption"
+)
+
+const (
+	bucketSSEConfig = "bucket-encryption.xml"
+)
+
+type BucketSSEConfigSys struct {
+	sync.RWMutex
+	bucketSSEConfigMap map[string]bucketsse.BucketSSEConfig
+}
+
+func (sys *BucketSSEConfigSys) Set(bucketName string, config bucketsse.BucketSSEConfig) {
+	sys.Lock()
+	defer sys.Unlock()
+
+	sys.bucketSSEConfigMap[bucketName] = config
+}
+
+func (sys *BucketSSEConfigSys) Get(bucketName string) (config bucketsse.BucketSSEConfig, ok bool) {
+	sys.RLock()
+	defer sys.RUnlock()
+
+	config, ok = sys.bucketSSEConfigMap[bucketName]
+	return config, ok
+}
+
+func (sys *BucketSSEConfigSys) Remove(bucketName string) {
+	sys.Lock()
+	defer sys.Unlock()
+
+	delete(sys.bucketSSEConfigMap, bucketName)
+}
+
+func (sys *BucketSSEConfigSys) load(buckets []BucketInfo, objAPI ObjectLayer) error {
+	for _, bucket := range buckets {
+		config, err := objAPI.GetBucketSSEConfig(GlobalContext, bucket.Name)
+		if err != nil {
+			if _, ok := err.(BucketSSEConfigNotFound); ok {
+				sys.Remove(bucket.Name)
+				continue
+			}
+			return err
+		}
+		sys.Set(bucket.Name, *config)
+	}
+	return nil
+}
+
+func (sys *BucketSSEConfigSys) Init(buckets []BucketInfo, objAPI ObjectLayer) error {
+	if objAPI == nil {
+		return errInvalidArgument
+	}
+
+	return sys.load(buckets, objAPI)
+}
+
+func NewBucketSSEConfigSys() *BucketSSEConfigSys {
+	return &BucketSSEConfigSys{
+		bucketSSEConfigMap: make(map[string]bucketsse.BucketSSEConfig),
+	}
+}
+
+func getBucketSSEConfig(objAPI ObjectLayer, bucketName string) (*bucketsse.BucketSSEConfig, error) {
+	configFile := path.Join(bucketConfigPrefix, bucketName, bucketSSEConfig)
+
+	configData, err := readConfig(GlobalContext, objAPI, configFile)
+	if err != nil {
+		if err == errConfigNotFound {
+			err = BucketSSEConfigNotFound{Bucket: bucketName}
+		}
+
+		return nil, err
+	}
+
+	return bucketsse.ParseBucketSSEConfig(bytes.NewReader(configData))
+}
+
+func saveBucketSSEConfig(ctx context.Context, objAPI ObjectLayer, bucketName string, config *bucketsse.BucketSSEConfig) error {
+	data, err := xml.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	configFile := path.Join(bucketConfigPrefix, bucketName, bucketSSEConfig)
+
+	return saveConfig(ctx, objAPI, configFile, data)
+}
+
+func removeBucketSSEConfig(ctx context.Context, objAPI ObjectLayer, bucketName string) error {
+	configFile := path.Join(bucketConfigPrefix, bucketName, bucketSSEConfig)
+
+	if err := objAPI.DeleteObject(ctx, iposMetaBucket, configFile); err != nil {
+		if _, ok := err.(ObjectNotFound); ok {
+			return BucketSSEConfigNotFound{Bucket: bucketName}
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func applyDefaultEncryption(h http.Header, bucket string) {
+	if crypto.S3.IsRequested(h) || crypto.SSEC.IsRequested(h) || crypto.S3KMS.IsRequested(h) {
+		return
+	}
+
+	if config, ok := globalBucketSSEConfigSys.Get(bucket); ok {
+		action := config.Rules[0].DefaultEncryptionAction
+		switch action.Algorithm {
+		case bucketsse.AES256:
+			h.Set(crypto.SSEHeader, crypto.SSEAlgorithmAES256)
+		case bucketsse.AWSKms:
+			h.Set(crypto.SSEHeader, crypto.SSEAlgorithmKMS)
+			h.Set(crypto.SSEKmsID, action.MasterKeyID)
+		}
+		return
+	}
+
+	if globalAutoEncryption {
+		h.Set(crypto.SSEHeader, crypto.SSEAlgorithmAES256)
+	}
+}
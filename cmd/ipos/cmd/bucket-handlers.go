@@ -1,16 +1,21 @@
 package cmd
 
 import (
+	"crypto/subtle"
+	"encoding/base64"
 	"net/http"
+	"net/url"
 	"path"
 	"strings"
 
 	"github.com/gorilla/mux"
 
+	"github.com/storeros/ipos/cmd/ipos/crypto"
 	xhttp "github.com/storeros/ipos/cmd/ipos/http"
 	"github.com/storeros/ipos/cmd/ipos/logger"
 	objectlock "github.com/storeros/ipos/pkg/bucket/object/lock"
 	"github.com/storeros/ipos/pkg/bucket/policy"
+	"github.com/storeros/ipos/pkg/hash"
 	iampolicy "github.com/storeros/ipos/pkg/iam/policy"
 )
 
@@ -360,5 +365,295 @@ func (api objectAPIHandlers) DeleteBucketHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
+	globalPolicySys.Remove(bucket)
+	globalLifecycleSys.Remove(bucket)
+	globalBucketSSEConfigSys.Remove(bucket)
+	globalBucketPinningSys.Remove(bucket)
+
 	writeSuccessNoContent(w)
 }
+
+func (api objectAPIHandlers) ListMultipartUploadsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "ListMultipartUploads")
+
+	defer logger.AuditLog(w, r, "ListMultipartUploads", mustGetClaimsFromToken(r))
+
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if s3Error := checkRequestAuthType(ctx, r, policy.ListBucketMultipartUploadsAction, bucket, ""); s3Error != ErrNone {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Error), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	prefix, keyMarker, uploadIDMarker, delimiter, maxUploads, encodingType, errCode := getBucketMultipartResources(r.URL.Query())
+	if errCode != ErrNone {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(errCode), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if maxUploads < 0 {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidMaxUploads), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if keyMarker != "" {
+		if !HasPrefix(keyMarker, prefix) {
+			writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrNotImplemented), r.URL, guessIsBrowserReq(r))
+			return
+		}
+	}
+
+	listMultipartsInfo, err := objectAPI.ListMultipartUploads(ctx, bucket, prefix, keyMarker, uploadIDMarker, delimiter, maxUploads)
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	response := generateListMultipartUploadsResponse(bucket, listMultipartsInfo, encodingType)
+	encodedSuccessResponse := encodeResponse(response)
+
+	writeSuccessResponseXML(w, encodedSuccessResponse)
+}
+
+func (api objectAPIHandlers) PostPolicyBucketHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "PostPolicyBucket")
+
+	defer logger.AuditLog(w, r, "PostPolicyBucket", mustGetClaimsFromToken(r))
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	bucket := mux.Vars(r)["bucket"]
+
+	if r.ContentLength < 0 {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrMissingContentLength), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	resource, err := getResource(r.URL.Path, r.Host, globalDomainNames)
+	if err != nil {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidRequest), r.URL, guessIsBrowserReq(r))
+		return
+	}
+	if bucket != path.Clean(resource[1:]) {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrMethodNotAllowed), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		logger.LogIf(ctx, err, logger.Application)
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrMalformedPOSTRequest), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	form, err := reader.ReadForm(maxFormMemory)
+	if err != nil {
+		logger.LogIf(ctx, err, logger.Application)
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrMalformedPOSTRequest), r.URL, guessIsBrowserReq(r))
+		return
+	}
+	defer form.RemoveAll()
+
+	fileBody, fileName, fileSize, formValues, err := extractPostPolicyFormValues(ctx, form)
+	if err != nil {
+		logger.LogIf(ctx, err, logger.Application)
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrMalformedPOSTRequest), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if fileBody == nil {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrPOSTFileRequired), r.URL, guessIsBrowserReq(r))
+		return
+	}
+	defer fileBody.Close()
+
+	formValues.Set("Bucket", bucket)
+
+	if fileName != "" && strings.Contains(formValues.Get("Key"), "${filename}") {
+		formValues.Set("Key", strings.Replace(formValues.Get("Key"), "${filename}", fileName, -1))
+	}
+	object := formValues.Get("Key")
+	if object == "" {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrMalformedPOSTRequest), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	successRedirect := formValues.Get("success_action_redirect")
+	successStatus := formValues.Get("success_action_status")
+	var redirectURL *url.URL
+	if successRedirect != "" {
+		redirectURL, err = url.Parse(successRedirect)
+		if err != nil {
+			writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrMalformedPOSTRequest), r.URL, guessIsBrowserReq(r))
+			return
+		}
+	}
+
+	cred, errCode := doesPolicySignatureMatch(formValues)
+	if errCode != ErrNone {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(errCode), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	token := formValues.Get(xhttp.AmzSecurityToken)
+	if cred.IsServiceAccount() && token == "" {
+		token = cred.SessionToken
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(cred.SessionToken)) != 1 {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidToken), r.URL, guessIsBrowserReq(r))
+		return
+	}
+	claims, err := getClaimsFromToken(r, token)
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if !globalIAMSys.IsAllowed(iampolicy.Args{
+		AccountName:     cred.AccessKey,
+		Action:          iampolicy.PutObjectAction,
+		BucketName:      bucket,
+		ConditionValues: getConditionValues(r, "", cred.AccessKey, claims),
+		ObjectName:      object,
+		IsOwner:         globalActiveCred.AccessKey == cred.AccessKey,
+		Claims:          claims,
+	}) {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrAccessDenied), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	policyBytes, err := base64.StdEncoding.DecodeString(formValues.Get("Policy"))
+	if err != nil {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrMalformedPOSTRequest), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if len(policyBytes) > 0 {
+		postPolicyForm, err := parsePostPolicyForm(string(policyBytes))
+		if err != nil {
+			writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrPostPolicyConditionInvalidFormat), r.URL, guessIsBrowserReq(r))
+			return
+		}
+
+		if err = checkPostPolicy(formValues, postPolicyForm); err != nil {
+			writeCustomErrorResponseXML(ctx, w, errorCodes.ToAPIErr(ErrAccessDenied), err.Error(), r.URL, guessIsBrowserReq(r))
+			return
+		}
+
+		lengthRange := postPolicyForm.Conditions.ContentLengthRange
+		if lengthRange.Valid {
+			if fileSize < lengthRange.Min {
+				writeErrorResponse(ctx, w, toAPIError(ctx, errDataTooSmall), r.URL, guessIsBrowserReq(r))
+				return
+			}
+
+			if fileSize > lengthRange.Max || isMaxObjectSize(fileSize) {
+				writeErrorResponse(ctx, w, toAPIError(ctx, errDataTooLarge), r.URL, guessIsBrowserReq(r))
+				return
+			}
+		}
+	}
+
+	metadata := make(map[string]string)
+	if err = extractMetadataFromMap(ctx, formValues, metadata); err != nil {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInternalError), r.URL, guessIsBrowserReq(r))
+		return
+	}
+	if _, ok := metadata["content-type"]; !ok {
+		metadata["content-type"] = "application/octet-stream"
+	}
+
+	if objectAPI.IsEncryptionSupported() && !HasSuffix(object, SlashSeparator) {
+		applyDefaultEncryption(formValues, bucket)
+	}
+
+	putReaders, err := newPutObjectReaders(objectAPI, formValues, object, fileBody, fileSize, "", "", metadata)
+	if err != nil {
+		logger.LogIf(ctx, err)
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+	defer putReaders.Close()
+
+	hashReader := putReaders.hashReader
+	pReader := putReaders.PutObjReader()
+	if objectAPI.IsEncryptionSupported() && !HasSuffix(object, SlashSeparator) {
+		if crypto.IsRequested(formValues) {
+			if crypto.SSECopy.IsRequested(formValues) {
+				writeErrorResponse(ctx, w, toAPIError(ctx, errInvalidEncryptionParameters), r.URL, guessIsBrowserReq(r))
+				return
+			}
+
+			reader, objectEncryptionKey, err := encryptRequestHeader(hashReader, formValues, fileSize, bucket, object, metadata)
+			if err != nil {
+				writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+				return
+			}
+			info := ObjectInfo{Size: fileSize}
+			encReader, err := hash.NewReader(reader, info.EncryptedSize(), "", "", fileSize, globalCLIContext.StrictS3Compat)
+			if err != nil {
+				writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+				return
+			}
+			pReader = NewPutObjReader(hashReader, encReader, &objectEncryptionKey)
+		}
+	}
+	crypto.RemoveSensitiveEntries(metadata)
+
+	objInfo, err := objectAPI.PutObject(ctx, bucket, object, pReader, ObjectOptions{
+		UserDefined:        metadata,
+		CompressionIndexFn: putReaders.indexFn,
+	})
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	switch {
+	case crypto.S3.IsEncrypted(objInfo.UserDefined):
+		w.Header().Set(crypto.SSEHeader, crypto.SSEAlgorithmAES256)
+	case crypto.SSEC.IsEncrypted(objInfo.UserDefined):
+		w.Header().Set(crypto.SSECAlgorithm, formValues.Get(crypto.SSECAlgorithm))
+		w.Header().Set(crypto.SSECKeyMD5, formValues.Get(crypto.SSECKeyMD5))
+	}
+	objInfo.ETag = getDecryptedETag(formValues, objInfo, false)
+
+	location := getObjectLocation(r, globalDomainNames, bucket, object)
+	w.Header()[xhttp.ETag] = []string{`"` + objInfo.ETag + `"`}
+	w.Header().Set(xhttp.Location, location)
+	if objInfo.CID != "" {
+		w.Header().Set(xhttp.IPOSCID, objInfo.CID)
+	}
+
+	if successRedirect != "" {
+		redirectURL.RawQuery = getRedirectPostRawQuery(objInfo)
+		writeRedirectSeeOther(w, redirectURL.String())
+		return
+	}
+
+	switch successStatus {
+	case "201":
+		resp := encodeResponse(PostResponse{
+			Bucket:   objInfo.Bucket,
+			Key:      objInfo.Name,
+			ETag:     `"` + objInfo.ETag + `"`,
+			Location: location,
+		})
+		writeResponse(w, http.StatusCreated, resp, mimeXML)
+	case "200":
+		writeSuccessResponseHeadersOnly(w)
+	default:
+		writeSuccessNoContent(w)
+	}
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/storeros/ipos/pkg/bucket/pinning"
+)
+
+const (
+	bucketPinningConfig = "pinning.json"
+)
+
+type ObjectPinInfo struct {
+	CID       string `json:"cid,omitempty"`
+	Local     string `json:"local,omitempty"`
+	Remote    string `json:"remote,omitempty"`
+	RequestID string `json:"requestid,omitempty"`
+}
+
+func (p ObjectPinInfo) String() string {
+	var status []string
+	if p.Local != "" {
+		status = append(status, "local="+p.Local)
+	}
+	if p.Remote != "" {
+		status = append(status, "remote="+p.Remote)
+	}
+	return strings.Join(status, ";")
+}
+
+type BucketPinningSys struct {
+	sync.RWMutex
+	bucketPinningConfigMap map[string]pinning.Config
+}
+
+func (sys *BucketPinningSys) Set(bucketName string, config pinning.Config) {
+	sys.Lock()
+	defer sys.Unlock()
+
+	sys.bucketPinningConfigMap[bucketName] = config
+}
+
+func (sys *BucketPinningSys) Get(bucketName string) (config pinning.Config, ok bool) {
+	sys.RLock()
+	defer sys.RUnlock()
+
+	config, ok = sys.bucketPinningConfigMap[bucketName]
+	return config, ok
+}
+
+func (sys *BucketPinningSys) Remove(bucketName string) {
+	sys.Lock()
+	defer sys.Unlock()
+
+	delete(sys.bucketPinningConfigMap, bucketName)
+}
+
+func (sys *BucketPinningSys) load(buckets []BucketInfo, objAPI ObjectLayer) error {
+	for _, bucket := range buckets {
+		config, err := objAPI.GetBucketPinning(GlobalContext, bucket.Name)
+		if err != nil {
+			if _, ok := err.(BucketPinningConfigNotFound); ok {
+				sys.Remove(bucket.Name)
+				continue
+			}
+			return err
+		}
+		sys.Set(bucket.Name, *config)
+	}
+	return nil
+}
+
+func (sys *BucketPinningSys) Init(buckets []BucketInfo, objAPI ObjectLayer) error {
+	if objAPI == nil {
+		return errInvalidArgument
+	}
+
+	return sys.load(buckets, objAPI)
+}
+
+func NewBucketPinningSys() *BucketPinningSys {
+	return &BucketPinningSys{
+		bucketPinningConfigMap: make(map[string]pinning.Config),
+	}
+}
+
+func getBucketPinningConfig(objAPI ObjectLayer, bucketName string) (*pinning.Config, error) {
+	configFile := path.Join(bucketConfigPrefix, bucketName, bucketPinningConfig)
+
+	configData, err := readConfig(GlobalContext, objAPI, configFile)
+	if err != nil {
+		if err == errConfigNotFound {
+			err = BucketPinningConfigNotFound{Bucket: bucketName}
+		}
+
+		return nil, err
+	}
+
+	return pinning.ParseConfig(bytes.NewReader(configData))
+}
+
+func saveBucketPinningConfig(ctx context.Context, objAPI ObjectLayer, bucketName string, config *pinning.Config) error {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	configFile := path.Join(bucketConfigPrefix, bucketName, bucketPinningConfig)
+
+	return saveConfig(ctx, objAPI, configFile, data)
+}
+
+func removeBucketPinningConfig(ctx context.Context, objAPI ObjectLayer, bucketName string) error {
+	configFile := path.Join(bucketConfigPrefix, bucketName, bucketPinningConfig)
+
+	if err := objAPI.DeleteObject(ctx, iposMetaBucket, configFile); err != nil {
+		if _, ok := err.(ObjectNotFound); ok {
+			return BucketPinningConfigNotFound{Bucket: bucketName}
+		}
+
+		return err
+	}
+
+	return nil
+}
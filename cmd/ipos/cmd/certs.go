@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/storeros/ipos/pkg/certs"
+)
+
+func parsePublicCertFile(certFile string) (x509Certs []*x509.Certificate, err error) {
+	var data []byte
+	if data, err = ioutil.ReadFile(certFile); err != nil {
+		return nil, err
+	}
+
+	current := data
+	for len(current) > 0 {
+		var pemBlock *pem.Block
+		if pemBlock, current = pem.Decode(current); pemBlock == nil {
+			return nil, fmt.Errorf("Could not read PEM block from file %s", certFile)
+		}
+
+		var x509Cert *x509.Certificate
+		if x509Cert, err = x509.ParseCertificate(pemBlock.Bytes); err != nil {
+			return nil, fmt.Errorf("Failed to parse `%s`: %w", certFile, err)
+		}
+
+		x509Certs = append(x509Certs, x509Cert)
+	}
+
+	if len(x509Certs) == 0 {
+		return nil, fmt.Errorf("Empty public certificate file %s", certFile)
+	}
+
+	return x509Certs, nil
+}
+
+func getRootCAs(certsCAsDir string) (*x509.CertPool, error) {
+	rootCAs, _ := x509.SystemCertPool()
+	if rootCAs == nil {
+		rootCAs = x509.NewCertPool()
+	}
+
+	fis, err := ioutil.ReadDir(certsCAsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return rootCAs, err
+	}
+
+	for _, fi := range fis {
+		if fi.IsDir() {
+			continue
+		}
+		caCert, err := ioutil.ReadFile(filepath.Join(certsCAsDir, fi.Name()))
+		if err != nil {
+			return rootCAs, err
+		}
+		rootCAs.AppendCertsFromPEM(caCert)
+	}
+	return rootCAs, nil
+}
+
+func loadX509KeyPair(certFile, keyFile string) (tls.Certificate, error) {
+	certPEMBlock, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("Unable to read the public key: %w", err)
+	}
+	keyPEMBlock, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("Unable to read the private key: %w", err)
+	}
+	key, rest := pem.Decode(keyPEMBlock)
+	if len(rest) > 0 {
+		return tls.Certificate{}, errors.New("The private key contains additional data")
+	}
+	if key == nil {
+		return tls.Certificate{}, errors.New("The private key is not PEM encoded")
+	}
+	if x509.IsEncryptedPEMBlock(key) {
+		return tls.Certificate{}, errors.New("Encrypted private keys are not supported")
+	}
+	cert, err := tls.X509KeyPair(certPEMBlock, keyPEMBlock)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+		return tls.Certificate{}, err
+	}
+	return cert, nil
+}
+
+type sniCerts struct {
+	defaultCert *certs.Certs
+	domainCerts []*certs.Certs
+}
+
+func (s *sniCerts) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if hello.ServerName != "" {
+		for _, c := range s.domainCerts {
+			cert, err := c.GetCertificate(hello)
+			if err != nil {
+				continue
+			}
+			if cert.Leaf != nil && cert.Leaf.VerifyHostname(hello.ServerName) == nil {
+				return cert, nil
+			}
+		}
+	}
+	if s.defaultCert != nil {
+		return s.defaultCert.GetCertificate(hello)
+	}
+	return s.domainCerts[0].GetCertificate(hello)
+}
+
+func getTLSConfig() (x509Certs []*x509.Certificate, getCert certs.GetCertificateFunc, secureConn bool, err error) {
+	s := &sniCerts{}
+
+	if isFile(getPublicCertFile()) && isFile(getPrivateKeyFile()) {
+		if x509Certs, err = parsePublicCertFile(getPublicCertFile()); err != nil {
+			return nil, nil, false, err
+		}
+
+		if s.defaultCert, err = certs.New(getPublicCertFile(), getPrivateKeyFile(), loadX509KeyPair); err != nil {
+			return nil, nil, false, err
+		}
+	}
+
+	fis, err := ioutil.ReadDir(globalCertsDir.Get())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, false, err
+	}
+	for _, fi := range fis {
+		if !fi.IsDir() || fi.Name() == certsCADir {
+			continue
+		}
+
+		certFile := filepath.Join(globalCertsDir.Get(), fi.Name(), publicCertFile)
+		keyFile := filepath.Join(globalCertsDir.Get(), fi.Name(), privateKeyFile)
+		if !isFile(certFile) || !isFile(keyFile) {
+			continue
+		}
+
+		domainX509Certs, err := parsePublicCertFile(certFile)
+		if err != nil {
+			return nil, nil, false, err
+		}
+
+		c, err := certs.New(certFile, keyFile, loadX509KeyPair)
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("Unable to load certificate for %s: %w", fi.Name(), err)
+		}
+
+		x509Certs = append(x509Certs, domainX509Certs...)
+		s.domainCerts = append(s.domainCerts, c)
+	}
+
+	if s.defaultCert == nil && len(s.domainCerts) == 0 {
+		return nil, nil, false, nil
+	}
+
+	return x509Certs, s.GetCertificate, true, nil
+}
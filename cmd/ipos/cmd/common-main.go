@@ -1,11 +1,15 @@
 package cmd
 
 import (
+	"path/filepath"
+	"strings"
+
 	"github.com/storeros/ipos/cmd/ipos/config"
 	"github.com/storeros/ipos/cmd/ipos/logger"
 	"github.com/storeros/ipos/pkg/auth"
 	"github.com/storeros/ipos/pkg/cli"
 	"github.com/storeros/ipos/pkg/env"
+	"github.com/storeros/ipos/pkg/s3utils"
 )
 
 func init() {
@@ -41,6 +45,20 @@ func handleCommonCmdArgs(ctx *cli.Context) {
 	if ctx.IsSet("no-compat") || ctx.GlobalIsSet("no-compat") {
 		globalCLIContext.StrictS3Compat = false
 	}
+
+	if ctx.IsSet("certs-dir") || ctx.GlobalIsSet("certs-dir") {
+		certsDir := ctx.String("certs-dir")
+		if certsDir == "" {
+			certsDir = ctx.GlobalString("certs-dir")
+		}
+		if certsDir == "" {
+			logger.FatalIf(errInvalidArgument, "Invalid certs directory provided")
+		}
+		globalCertsDir = &ConfigDir{path: certsDir}
+		globalCertsCADir = &ConfigDir{path: filepath.Join(globalCertsDir.Get(), certsCADir)}
+	}
+
+	logger.FatalIf(globalCertsCADir.Mkdir(), "Unable to create certs CA directory at %s", globalCertsCADir.Get())
 }
 
 func handleCommonEnvVars() {
@@ -52,6 +70,16 @@ func handleCommonEnvVars() {
 		globalActiveCred = cred
 		globalConfigEncrypted = true
 	}
+
+	if domains := env.Get(config.EnvDomain, ""); len(domains) != 0 {
+		for _, domainName := range strings.Split(domains, config.ValueSeparator) {
+			if !s3utils.IsValidDomain(domainName) {
+				logger.Fatal(config.ErrInvalidDomainValue(nil).Msg("Unknown value `%s`", domainName),
+					"Invalid IPOS_DOMAIN value in environment variable")
+			}
+			globalDomainNames = append(globalDomainNames, domainName)
+		}
+	}
 }
 
 func logStartupMessage(msg string) {
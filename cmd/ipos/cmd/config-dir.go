@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+const (
+	defaultIPOSConfigDir = ".ipos"
+
+	certsDir = "certs"
+
+	certsCADir = "CAs"
+
+	publicCertFile = "public.crt"
+
+	privateKeyFile = "private.key"
+)
+
+type ConfigDir struct {
+	path string
+}
+
+func getDefaultCertsDir() string {
+	homeDir, err := homedir.Dir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(homeDir, defaultIPOSConfigDir, certsDir)
+}
+
+var (
+	defaultCertsDir = &ConfigDir{path: getDefaultCertsDir()}
+
+	defaultCertsCADir = &ConfigDir{path: filepath.Join(defaultCertsDir.Get(), certsCADir)}
+
+	globalCertsDir = defaultCertsDir
+
+	globalCertsCADir = defaultCertsCADir
+)
+
+func (dir *ConfigDir) Get() string {
+	return dir.path
+}
+
+func (dir *ConfigDir) Mkdir() error {
+	return mkdirAllIgnorePerm(dir.path)
+}
+
+func mkdirAllIgnorePerm(path string) error {
+	err := os.MkdirAll(path, 0700)
+	if err != nil && os.IsPermission(err) {
+		// Read-only certs directories are fine as long as they exist.
+		if _, serr := os.Stat(path); serr == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func getPublicCertFile() string {
+	return filepath.Join(globalCertsDir.Get(), publicCertFile)
+}
+
+func getPrivateKeyFile() string {
+	return filepath.Join(globalCertsDir.Get(), privateKeyFile)
+}
@@ -90,3 +90,5 @@ func (sys *HTTPConsoleLoggerSys) Send(e interface{}, logKind string) error {
 
 	return sys.console.Send(e, string(logger.All))
 }
+
+func (sys *HTTPConsoleLoggerSys) Cancel() {}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+func writeCopyPartErr(ctx context.Context, w http.ResponseWriter, err error, url *url.URL, browser bool) {
+	switch err {
+	case errInvalidRange:
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidCopyPartRange), url, browser)
+		return
+	case errInvalidRangeSource:
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidCopyPartRangeSource), url, browser)
+		return
+	default:
+		apiErr := errorCodes.ToAPIErr(ErrInvalidCopyPartRangeSource)
+		apiErr.Description = err.Error()
+		writeErrorResponse(ctx, w, apiErr, url, browser)
+		return
+	}
+}
+
+func parseCopyPartRangeSpec(rangeString string) (hrange *HTTPRangeSpec, err error) {
+	hrange, err = parseRequestRangeSpec(rangeString)
+	if err != nil {
+		return nil, err
+	}
+	if hrange.IsSuffixLength || hrange.Start < 0 || hrange.End < 0 {
+		return nil, errInvalidRange
+	}
+	return hrange, nil
+}
+
+func checkCopyPartRangeWithSize(rs *HTTPRangeSpec, resourceSize int64) error {
+	if rs == nil {
+		return nil
+	}
+	if rs.IsSuffixLength || rs.Start >= resourceSize || rs.End >= resourceSize {
+		return errInvalidRangeSource
+	}
+	return nil
+}
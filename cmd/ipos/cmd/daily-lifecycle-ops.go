@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/storeros/ipos/cmd/ipos/logger"
+	"github.com/storeros/ipos/pkg/bucket/lifecycle"
+)
+
+const (
+	bgLifecycleInterval = 24 * time.Hour
+	bgLifecycleTick     = time.Hour
+
+	lifecycleDeleteBatch = 1000
+)
+
+type lifecycleOps struct {
+	LastActivity time.Time
+}
+
+var globalLifecycleOps = &lifecycleOps{}
+
+func startDailyLifecycle(ctx context.Context, objAPI ObjectLayer) {
+	ticker := time.NewTicker(bgLifecycleTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if time.Since(globalLifecycleOps.LastActivity) < bgLifecycleInterval {
+				continue
+			}
+
+			if err := lifecycleRound(ctx, objAPI); err != nil {
+				logger.LogIf(ctx, err)
+				continue
+			}
+
+			globalLifecycleOps.LastActivity = UTCNow()
+		}
+	}
+}
+
+func initDailyLifecycle(ctx context.Context, objAPI ObjectLayer) {
+	go startDailyLifecycle(ctx, objAPI)
+}
+
+func lifecycleRound(ctx context.Context, objAPI ObjectLayer) error {
+	buckets, err := objAPI.ListBuckets(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, bucket := range buckets {
+		l, ok := globalLifecycleSys.Get(bucket.Name)
+		if !ok {
+			continue
+		}
+
+		var prefixes []string
+		for _, rule := range l.Rules {
+			if rule.Status != lifecycle.Enabled {
+				continue
+			}
+			prefixes = append(prefixes, rule.Prefix())
+		}
+		if len(prefixes) == 0 {
+			continue
+		}
+
+		results := make(chan ObjectInfo)
+		if err = objAPI.Walk(ctx, bucket.Name, lcp(prefixes), results); err != nil {
+			logger.LogIf(ctx, err)
+			continue
+		}
+
+		var objects []string
+		for obj := range results {
+			if obj.IsDir {
+				continue
+			}
+			if l.ComputeAction(obj.Name, obj.UserTags, obj.ModTime) != lifecycle.DeleteAction {
+				continue
+			}
+			if enforceRetentionForLifecycle(ctx, obj) {
+				continue
+			}
+
+			objects = append(objects, obj.Name)
+			if len(objects) == lifecycleDeleteBatch {
+				expireObjects(ctx, objAPI, bucket.Name, objects)
+				objects = nil
+			}
+		}
+
+		if len(objects) > 0 {
+			expireObjects(ctx, objAPI, bucket.Name, objects)
+		}
+	}
+
+	return nil
+}
+
+func expireObjects(ctx context.Context, objAPI ObjectLayer, bucket string, objects []string) {
+	errs, err := objAPI.DeleteObjects(ctx, bucket, objects)
+	if err != nil {
+		logger.LogIf(ctx, err)
+		return
+	}
+
+	for i, object := range objects {
+		logger.LogIf(ctx, errs[i])
+		logger.AuditEvent("LifecycleExpiry", bucket, object, errs[i])
+	}
+}
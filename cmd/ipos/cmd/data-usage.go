@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"time"
+
+	"github.com/storeros/ipos/cmd/ipos/logger"
+)
+
+const (
+	dataUsageObjName       = ".usage.json"
+	dataUsageCrawlInterval = 12 * time.Hour
+	dataUsageStartDelay    = 5 * time.Minute
+)
+
+func initDataUsageStats(ctx context.Context, objAPI ObjectLayer) {
+	go runDataUsageInfo(ctx, objAPI)
+}
+
+func runDataUsageInfo(ctx context.Context, objAPI ObjectLayer) {
+	timer := time.NewTimer(dataUsageStartDelay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			updates := make(chan DataUsageInfo, 1)
+			go storeDataUsageInBackend(ctx, objAPI, updates)
+			logger.LogIf(ctx, objAPI.CrawlAndGetDataUsage(ctx, updates))
+			timer.Reset(dataUsageCrawlInterval)
+		}
+	}
+}
+
+func storeDataUsageInBackend(ctx context.Context, objAPI ObjectLayer, updates <-chan DataUsageInfo) {
+	for dataUsageInfo := range updates {
+		dataUsageJSON, err := json.Marshal(dataUsageInfo)
+		if err != nil {
+			logger.LogIf(ctx, err)
+			continue
+		}
+
+		logger.LogIf(ctx, saveConfig(ctx, objAPI, path.Join(bucketConfigPrefix, dataUsageObjName), dataUsageJSON))
+	}
+}
+
+func loadDataUsageFromBackend(ctx context.Context, objAPI ObjectLayer) (DataUsageInfo, error) {
+	dataUsageInfo := DataUsageInfo{}
+
+	dataUsageJSON, err := readConfig(ctx, objAPI, path.Join(bucketConfigPrefix, dataUsageObjName))
+	if err != nil {
+		if err == errConfigNotFound {
+			return dataUsageInfo, nil
+		}
+		return dataUsageInfo, err
+	}
+
+	if err = json.Unmarshal(dataUsageJSON, &dataUsageInfo); err != nil {
+		return dataUsageInfo, err
+	}
+
+	return dataUsageInfo, nil
+}
@@ -25,6 +25,7 @@ var (
 	errEncryptedObject             = errors.New("The object was stored using a form of SSE")
 	errInvalidSSEParameters        = errors.New("The SSE-C key for key-rotation is not correct")
 	errKMSNotConfigured            = errors.New("KMS not configured for a server side encrypted object")
+	errKMSKeyNotFound              = errors.New("The specified KMS key ID does not exist")
 	errObjectTampered              = errors.New("The requested object was modified and may be compromised")
 	errInvalidEncryptionParameters = errors.New("The encryption parameters are not applicable to this object")
 )
@@ -152,37 +153,88 @@ func newEncryptReader(content io.Reader, key []byte, bucket, object string, meta
 	return reader, objectEncryptionKey, nil
 }
 
+func parseKMSRequest(h http.Header) (sseS3 bool, err error) {
+	switch {
+	case crypto.S3KMS.IsRequested(h):
+		keyID, _, err := crypto.S3KMS.ParseHTTP(h)
+		if err != nil {
+			return false, err
+		}
+		if GlobalKMS == nil {
+			return false, errKMSNotConfigured
+		}
+		if keyID != "" && keyID != GlobalKMS.KeyID() {
+			return false, errKMSKeyNotFound
+		}
+		return true, nil
+	case crypto.S3.IsRequested(h):
+		if err = crypto.S3.ParseHTTP(h); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
 func setEncryptionMetadata(r *http.Request, bucket, object string, metadata map[string]string) (err error) {
 	var (
-		key []byte
+		key   []byte
+		sseS3 bool
 	)
+	if sseS3, err = parseKMSRequest(r.Header); err != nil {
+		return
+	}
+	if sseS3 && crypto.SSEC.IsRequested(r.Header) {
+		return crypto.ErrIncompatibleEncryptionMethod
+	}
 	if crypto.SSEC.IsRequested(r.Header) {
 		key, err = ParseSSECustomerRequest(r)
 		if err != nil {
 			return
 		}
 	}
-	_, err = newEncryptMetadata(key, bucket, object, metadata, crypto.S3.IsRequested(r.Header))
+	_, err = newEncryptMetadata(key, bucket, object, metadata, sseS3)
 	return
 }
 
 func EncryptRequest(content io.Reader, r *http.Request, bucket, object string, metadata map[string]string) (io.Reader, crypto.ObjectKey, error) {
-	if crypto.S3.IsRequested(r.Header) && crypto.SSEC.IsRequested(r.Header) {
+	return encryptRequestHeader(content, r.Header, r.ContentLength, bucket, object, metadata)
+}
+
+func encryptRequestHeader(content io.Reader, h http.Header, size int64, bucket, object string, metadata map[string]string) (io.Reader, crypto.ObjectKey, error) {
+	sseS3, err := parseKMSRequest(h)
+	if err != nil {
+		return nil, crypto.ObjectKey{}, err
+	}
+	if sseS3 && crypto.SSEC.IsRequested(h) {
 		return nil, crypto.ObjectKey{}, crypto.ErrIncompatibleEncryptionMethod
 	}
-	if r.ContentLength > encryptBufferThreshold {
+	if size > encryptBufferThreshold {
 		content = bufio.NewReaderSize(content, encryptBufferSize)
 	}
 
 	var key []byte
-	if crypto.SSEC.IsRequested(r.Header) {
-		var err error
-		key, err = ParseSSECustomerRequest(r)
+	if crypto.SSEC.IsRequested(h) {
+		key, err = ParseSSECustomerHeader(h)
 		if err != nil {
 			return nil, crypto.ObjectKey{}, err
 		}
 	}
-	return newEncryptReader(content, key, bucket, object, metadata, crypto.S3.IsRequested(r.Header))
+	return newEncryptReader(content, key, bucket, object, metadata, sseS3)
+}
+
+func getMultipartObjectKey(h http.Header, bucket, object string, metadata map[string]string) (objectKey crypto.ObjectKey, err error) {
+	var key []byte
+	if crypto.SSEC.IsRequested(h) {
+		if key, err = ParseSSECustomerHeader(h); err != nil {
+			return objectKey, err
+		}
+	}
+	if key, err = decryptObjectInfo(key, bucket, object, metadata); err != nil {
+		return objectKey, err
+	}
+	copy(objectKey[:], key)
+	return objectKey, nil
 }
 
 func DecryptCopyRequest(client io.Writer, r *http.Request, bucket, object string, metadata map[string]string) (io.WriteCloser, error) {
@@ -312,17 +364,41 @@ func DecryptBlocksRequestR(inputReader io.Reader, h http.Header, offset,
 	io.Reader, error) {
 
 	bucket, object := oi.Bucket, oi.Name
-	var reader io.Reader
-	var err error
-	if copySource {
-		reader, err = DecryptCopyRequestR(inputReader, h, bucket, object, seqNumber, oi.UserDefined)
-	} else {
-		reader, err = DecryptRequestWithSequenceNumberR(inputReader, h, bucket, object, seqNumber, oi.UserDefined)
+	if !isEncryptedMultipart(oi) {
+		var reader io.Reader
+		var err error
+		if copySource {
+			reader, err = DecryptCopyRequestR(inputReader, h, bucket, object, seqNumber, oi.UserDefined)
+		} else {
+			reader, err = DecryptRequestWithSequenceNumberR(inputReader, h, bucket, object, seqNumber, oi.UserDefined)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return reader, nil
 	}
-	if err != nil {
+
+	d := &DecryptBlocksReader{
+		reader:           inputReader,
+		startSeqNum:      seqNumber,
+		partIndex:        partStart,
+		parts:            oi.Parts,
+		header:           h,
+		bucket:           bucket,
+		object:           object,
+		partEncRelOffset: int64(seqNumber) * (SSEDAREPackageBlockSize + SSEDAREPackageMetaSize),
+		copySource:       copySource,
+	}
+
+	d.metadata = make(map[string]string, len(oi.UserDefined))
+	for k, v := range oi.UserDefined {
+		d.metadata[k] = v
+	}
+
+	if err := d.buildDecrypter(); err != nil {
 		return nil, err
 	}
-	return reader, nil
+	return d, nil
 }
 
 func DecryptRequestWithSequenceNumber(client io.Writer, r *http.Request, bucket, object string, seqNumber uint32, metadata map[string]string) (io.WriteCloser, error) {
@@ -346,25 +422,70 @@ type DecryptBlocksReader struct {
 	decrypter      io.Reader
 	startSeqNum    uint32
 	partIndex      int
+	parts          []ObjectPartInfo
 	header         http.Header
 	bucket, object string
 	metadata       map[string]string
 
-	partDecRelOffset, partEncRelOffset int64
+	partEncRelOffset int64
 
-	copySource        bool
-	customerKeyHeader string
+	copySource bool
 }
 
-func (d *DecryptBlocksReader) Read(p []byte) (int, error) {
-	var err error
-	var n1 int
-	n1, err = d.decrypter.Read(p)
+func (d *DecryptBlocksReader) buildDecrypter() error {
+	var (
+		key []byte
+		err error
+	)
+	if crypto.SSEC.IsEncrypted(d.metadata) {
+		if d.copySource {
+			key, err = ParseSSECopyCustomerRequest(d.header, d.metadata)
+		} else {
+			key, err = ParseSSECustomerHeader(d.header)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	objectEncryptionKey, err := decryptObjectInfo(key, d.bucket, d.object, d.metadata)
 	if err != nil {
-		return 0, err
+		return err
+	}
+
+	var objectKey crypto.ObjectKey
+	copy(objectKey[:], objectEncryptionKey)
+	partKey := objectKey.DerivePartKey(uint32(d.parts[d.partIndex].Number))
+
+	encLenToRead := d.parts[d.partIndex].Size - d.partEncRelOffset
+	d.decrypter, err = newDecryptReaderWithObjectKey(io.LimitReader(d.reader, encLenToRead), partKey[:], d.startSeqNum)
+	return err
+}
+
+func (d *DecryptBlocksReader) Read(p []byte) (int, error) {
+	for {
+		n, err := d.decrypter.Read(p)
+		if err != io.EOF {
+			return n, err
+		}
+		if n > 0 {
+			return n, nil
+		}
+
+		d.partIndex++
+		if d.partIndex == len(d.parts) {
+			return 0, io.EOF
+		}
+		d.startSeqNum = 0
+		d.partEncRelOffset = 0
+		if err = d.buildDecrypter(); err != nil {
+			return 0, err
+		}
 	}
-	d.partDecRelOffset += int64(n1)
-	return len(p), nil
+}
+
+func isEncryptedMultipart(objInfo ObjectInfo) bool {
+	return len(objInfo.Parts) > 0 && crypto.IsMultiPart(objInfo.UserDefined)
 }
 
 func getEncryptedSinglePartOffsetLength(offset, length int64, objInfo ObjectInfo) (seqNumber uint32, encOffset int64, encLength int64) {
@@ -389,11 +510,23 @@ func (o *ObjectInfo) DecryptedSize() (int64, error) {
 		return 0, errors.New("Cannot compute decrypted size of an unencrypted object")
 	}
 
-	size, err := sio.DecryptedSize(uint64(o.Size))
-	if err != nil {
-		err = errObjectTampered
+	if !isEncryptedMultipart(*o) {
+		size, err := sio.DecryptedSize(uint64(o.Size))
+		if err != nil {
+			err = errObjectTampered
+		}
+		return int64(size), err
+	}
+
+	var size int64
+	for _, part := range o.Parts {
+		partSize, err := sio.DecryptedSize(uint64(part.Size))
+		if err != nil {
+			return 0, errObjectTampered
+		}
+		size += int64(partSize)
 	}
-	return int64(size), err
+	return size, nil
 }
 
 func DecryptETag(key crypto.ObjectKey, object ObjectInfo) (string, error) {
@@ -484,13 +617,25 @@ func (o *ObjectInfo) GetDecryptedRange(rs *HTTPRangeSpec) (encOff, encLength, sk
 	var sizes []int64
 	var decObjSize int64
 	var partSize uint64
-	partSize, err = sio.DecryptedSize(uint64(o.Size))
-	if err != nil {
-		err = errObjectTampered
-		return
+	if isEncryptedMultipart(*o) {
+		for _, part := range o.Parts {
+			partSize, err = sio.DecryptedSize(uint64(part.Size))
+			if err != nil {
+				err = errObjectTampered
+				return
+			}
+			sizes = append(sizes, int64(partSize))
+			decObjSize += int64(partSize)
+		}
+	} else {
+		partSize, err = sio.DecryptedSize(uint64(o.Size))
+		if err != nil {
+			err = errObjectTampered
+			return
+		}
+		sizes = []int64{int64(partSize)}
+		decObjSize = sizes[0]
 	}
-	sizes = []int64{int64(partSize)}
-	decObjSize = sizes[0]
 
 	var off, length int64
 	off, length, err = rs.GetOffsetLength(decObjSize)
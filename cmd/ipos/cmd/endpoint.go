@@ -87,14 +87,14 @@ func NewEndpoint(arg string) (ep Endpoint, e error) {
 			return ep, fmt.Errorf("invalid URL endpoint format: empty host name")
 		}
 
-		u.Path = path.Clean(u.Path)
 		if isEmptyPath(u.Path) {
-			return ep, fmt.Errorf("empty or root path is not supported in URL endpoint")
-		}
-
-		if runtime.GOOS == globalWindowsOSName {
-			if filepath.VolumeName(u.Path[1:]) != "" {
-				u.Path = u.Path[1:]
+			u.Path = ""
+		} else {
+			u.Path = path.Clean(u.Path)
+			if runtime.GOOS == globalWindowsOSName {
+				if filepath.VolumeName(u.Path[1:]) != "" {
+					u.Path = u.Path[1:]
+				}
 			}
 		}
 
@@ -129,6 +129,36 @@ func NewEndpoints(args ...string) (endpoints Endpoints, err error) {
 	return endpoints, nil
 }
 
+func (endpoints Endpoints) Peers() Endpoints {
+	if len(endpoints) < 2 {
+		return nil
+	}
+	return endpoints[1:]
+}
+
+func (endpoints Endpoints) LocalPeer() (Endpoint, bool) {
+	for _, peer := range endpoints.Peers() {
+		if peer.IsLocal {
+			return peer, true
+		}
+	}
+	return Endpoint{}, false
+}
+
+func (endpoints Endpoints) UpdateIsLocal() error {
+	for i := range endpoints {
+		if endpoints[i].Type() != URLEndpointType {
+			continue
+		}
+		isLocal, err := isLocalHost(endpoints[i].Hostname(), endpoints[i].Port(), globalIPOSPort)
+		if err != nil {
+			return fmt.Errorf("'%s': %w", endpoints[i], err)
+		}
+		endpoints[i].IsLocal = isLocal
+	}
+	return nil
+}
+
 func createServerEndpoints(args ...string) (endpoints Endpoints, err error) {
 	if len(args) == 0 {
 		return nil, errInvalidArgument
@@ -139,5 +169,42 @@ func createServerEndpoints(args ...string) (endpoints Endpoints, err error) {
 		return nil, err
 	}
 
+	peers := endpoints.Peers()
+	if len(peers) == 0 {
+		return endpoints, nil
+	}
+
+	for _, peer := range peers {
+		if peer.Type() != URLEndpointType {
+			return nil, fmt.Errorf("'%s': peer endpoint must be a URL", peer)
+		}
+	}
+
+	if err = peers.UpdateIsLocal(); err != nil {
+		return nil, err
+	}
+
+	var localPeers int
+	for _, peer := range peers {
+		if peer.IsLocal {
+			localPeers++
+		}
+	}
+	switch localPeers {
+	case 0:
+		return nil, fmt.Errorf("none of the peer endpoints refer to this server")
+	case 1:
+	default:
+		return nil, fmt.Errorf("more than one peer endpoint refers to this server")
+	}
+
 	return endpoints, nil
 }
+
+func getLocalNodeID(endpoints Endpoints) string {
+	peer, ok := endpoints.LocalPeer()
+	if !ok {
+		return "local"
+	}
+	return strings.NewReplacer(":", "-", "[", "", "]", "").Replace(peer.Host)
+}
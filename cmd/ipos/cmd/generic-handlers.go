@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"github.com/storeros/ipos/cmd/ipos/crypto"
 	"github.com/storeros/ipos/cmd/ipos/logger"
 	"net/http"
 	"strings"
@@ -67,6 +68,21 @@ func (h redirectHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.handler.ServeHTTP(w, r)
 }
 
+type httpStatsHandler struct {
+	handler http.Handler
+}
+
+func setHTTPStatsHandler(h http.Handler) http.Handler {
+	return httpStatsHandler{handler: h}
+}
+
+func (h httpStatsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	isS3Request := !strings.HasPrefix(r.URL.Path, iposReservedBucketPath)
+	r.Body = &recordTrafficRequest{ReadCloser: r.Body, isS3Request: isS3Request}
+	recordResponse := &recordTrafficResponse{ResponseWriter: w, isS3Request: isS3Request}
+	h.handler.ServeHTTP(recordResponse, r)
+}
+
 type criticalErrorHandler struct{ handler http.Handler }
 
 func (h criticalErrorHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -79,3 +95,15 @@ func (h criticalErrorHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 	}()
 	h.handler.ServeHTTP(w, r)
 }
+
+type sseTLSHandler struct{ handler http.Handler }
+
+func setSSETLSHandler(h http.Handler) http.Handler { return sseTLSHandler{h} }
+
+func (h sseTLSHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.TLS == nil && (crypto.SSEC.IsRequested(r.Header) || crypto.SSECopy.IsRequested(r.Header)) {
+		writeErrorResponse(r.Context(), w, errorCodes.ToAPIErr(ErrInsecureSSECustomerRequest), r.URL, guessIsBrowserReq(r))
+		return
+	}
+	h.handler.ServeHTTP(w, r)
+}
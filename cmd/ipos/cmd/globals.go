@@ -7,10 +7,15 @@ import (
 
 	humanize "github.com/dustin/go-humanize"
 
+	"github.com/storeros/ipos/cmd/ipos/config/compress"
+	"github.com/storeros/ipos/cmd/ipos/config/identity/openid"
+	"github.com/storeros/ipos/cmd/ipos/config/pinservice"
 	"github.com/storeros/ipos/cmd/ipos/crypto"
 	xhttp "github.com/storeros/ipos/cmd/ipos/http"
 	"github.com/storeros/ipos/pkg/auth"
 	objectlock "github.com/storeros/ipos/pkg/bucket/object/lock"
+	"github.com/storeros/ipos/pkg/certs"
+	"github.com/storeros/ipos/pkg/dsync"
 	"github.com/storeros/ipos/pkg/pubsub"
 )
 
@@ -28,10 +33,16 @@ const (
 const (
 	maxFormFieldSize = int64(1 * humanize.MiByte)
 
+	maxFormMemory = int64(5 * humanize.MiByte)
+
 	globalMaxSkewTime = 15 * time.Minute
 
 	globalRefreshIAMInterval = 5 * time.Minute
 
+	globalMultipartExpiry = time.Hour * 24 * 14
+
+	globalMultipartCleanupInterval = time.Hour * 24
+
 	maxLocationConstraintSize = 3 * humanize.MiByte
 )
 
@@ -51,27 +62,51 @@ var (
 	globalIPOSPort = globalIPOSDefaultPort
 	globalIPOSHost = ""
 
-	globalPolicySys *PolicySys
-	globalIAMSys    *IAMSys
+	globalPolicySys          *PolicySys
+	globalLifecycleSys       *LifecycleSys
+	globalBucketSSEConfigSys *BucketSSEConfigSys
+	globalBucketPinningSys   *BucketPinningSys
+	globalIAMSys             *IAMSys
+
+	globalOpenIDConfig *openid.Config
 
 	globalAPIThrottling apiThrottling
 
 	globalRootCAs *x509.CertPool
 
+	globalPublicCerts []*x509.Certificate
+
+	globalGetCertificate certs.GetCertificateFunc
+
 	globalIsSSL bool
 
 	globalHTTPServer        *xhttp.Server
 	globalHTTPServerErrorCh = make(chan error)
 	globalOSSignalCh        = make(chan os.Signal, 1)
 
+	globalShutdownTimeout = xhttp.DefaultShutdownTimeout
+
+	globalObjectTimeout    = newDynamicTimeout(5*time.Minute, 30*time.Second)
+	globalOperationTimeout = newDynamicTimeout(10*time.Minute, 5*time.Minute)
+
 	globalHTTPTrace = pubsub.New()
 
 	globalConsoleSys *HTTPConsoleLoggerSys
 
 	globalEndpoints Endpoints
 
+	globalIsDistributed bool
+
+	globalLockServer *localLocker
+
+	globalLockers []dsync.NetLocker
+
 	globalHTTPStats = newHTTPStats()
 
+	globalConnStats = newConnStats()
+
+	globalPrometheusAuthType = prometheusJWT
+
 	globalActiveCred auth.Credentials
 
 	globalOldCred auth.Credentials
@@ -86,11 +121,17 @@ var (
 
 	globalAutoEncryption bool
 
+	globalCompressConfig compress.Config
+
+	globalPinningService *pinservice.Config
+
 	standardExcludeCompressExtensions = []string{".gz", ".bz2", ".rar", ".zip", ".7z", ".xz", ".mp4", ".mkv", ".mov"}
 
 	standardExcludeCompressContentTypes = []string{"video/*", "audio/*", "application/zip", "application/x-gzip", "application/x-zip-compressed", " application/x-compress", "application/x-spoon"}
 
 	globalDeploymentID string
+
+	globalBootTime = UTCNow()
 )
 
 func getGlobalInfo() (globalInfo map[string]interface{}) {
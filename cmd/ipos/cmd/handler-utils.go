@@ -246,6 +246,9 @@ func extractPostPolicyFormValues(ctx context.Context, form *multipart.Form) (fil
 func httpTraceAll(f http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if !globalHTTPTrace.HasSubscribers() {
+			if len(logger.AuditTargets) > 0 {
+				w = logger.NewResponseWriter(w)
+			}
 			f.ServeHTTP(w, r)
 			return
 		}
@@ -257,6 +260,9 @@ func httpTraceAll(f http.HandlerFunc) http.HandlerFunc {
 func httpTraceHdrs(f http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if !globalHTTPTrace.HasSubscribers() {
+			if len(logger.AuditTargets) > 0 {
+				w = logger.NewResponseWriter(w)
+			}
 			f.ServeHTTP(w, r)
 			return
 		}
@@ -315,6 +321,23 @@ func getResource(path string, host string, domains []string) (string, error) {
 	return path, nil
 }
 
+func getVirtualHost(host, bucket string) (string, bool) {
+	hostName := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostName = h
+	}
+	// Dotted bucket names would not match a wildcard certificate.
+	if globalIsSSL && strings.Contains(bucket, ".") {
+		return host, false
+	}
+	for _, domain := range globalDomainNames {
+		if hostName == domain {
+			return bucket + "." + host, true
+		}
+	}
+	return host, false
+}
+
 var regexVersion = regexp.MustCompile(`(\w\d+)`)
 
 func extractAPIVersion(r *http.Request) string {
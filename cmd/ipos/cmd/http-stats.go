@@ -94,6 +94,7 @@ type HTTPStats struct {
 	currentS3Requests HTTPAPIStats
 	totalS3Requests   HTTPAPIStats
 	totalS3Errors     HTTPAPIStats
+	s3RequestsTTFB    *histogramVec
 }
 
 func durationStr(totalDuration, totalCount float64) string {
@@ -108,9 +109,12 @@ func (st *HTTPStats) updateStats(api string, r *http.Request, w *recordAPIStats,
 		if !successReq && w.respStatusCode != 0 {
 			st.totalS3Errors.Inc(api)
 		}
+		st.s3RequestsTTFB.Observe(api, durationSecs)
 	}
 }
 
 func newHTTPStats() *HTTPStats {
-	return &HTTPStats{}
+	return &HTTPStats{
+		s3RequestsTTFB: newHistogramVec(latencyBuckets),
+	}
 }
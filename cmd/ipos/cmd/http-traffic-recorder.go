@@ -13,6 +13,10 @@ type recordTrafficRequest struct {
 
 func (r *recordTrafficRequest) Read(p []byte) (n int, err error) {
 	n, err = r.ReadCloser.Read(p)
+	globalConnStats.incInputBytes(n)
+	if r.isS3Request {
+		globalConnStats.incS3InputBytes(n)
+	}
 	return n, err
 }
 
@@ -23,6 +27,10 @@ type recordTrafficResponse struct {
 
 func (r *recordTrafficResponse) Write(p []byte) (n int, err error) {
 	n, err = r.ResponseWriter.Write(p)
+	globalConnStats.incOutputBytes(n)
+	if r.isS3Request {
+		globalConnStats.incS3OutputBytes(n)
+	}
 	return n, err
 }
 
@@ -79,6 +79,15 @@ func parseRequestRangeSpec(rangeString string) (hrange *HTTPRangeSpec, err error
 
 	byteRangeString := strings.TrimPrefix(rangeString, byteRangePrefix)
 
+	if strings.Contains(byteRangeString, ",") {
+		for _, spec := range strings.Split(byteRangeString, ",") {
+			if _, err = parseRequestRangeSpec(byteRangePrefix + strings.TrimSpace(spec)); err != nil {
+				return nil, err
+			}
+		}
+		return nil, errMultipleRanges
+	}
+
 	sepIndex := strings.Index(byteRangeString, "-")
 	if sepIndex == -1 {
 		return nil, fmt.Errorf("'%s' does not have a valid range value", rangeString)
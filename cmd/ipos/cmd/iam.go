@@ -311,6 +311,14 @@ func (sys *IAMSys) Init(ctx context.Context, objAPI ObjectLayer) error {
 	return err
 }
 
+func (sys *IAMSys) Load(ctx context.Context) error {
+	if sys == nil || sys.store == nil {
+		return errServerNotInitialized
+	}
+
+	return sys.store.loadAll(ctx, sys)
+}
+
 func (sys *IAMSys) DeletePolicy(policyName string) error {
 	objectAPI := newObjectLayerWithoutSafeModeFn()
 	if objectAPI == nil || sys == nil || sys.store == nil {
@@ -474,6 +482,24 @@ func (sys *IAMSys) SetTempUser(accessKey string, cred auth.Credentials, policyNa
 	sys.store.lock()
 	defer sys.store.unlock()
 
+	if policyName != "" {
+		p, ok := sys.iamPolicyDocsMap[policyName]
+		if !ok {
+			return errInvalidArgument
+		}
+		if p.IsEmpty() {
+			delete(sys.iamUserPolicyMap, accessKey)
+			return nil
+		}
+
+		mp := newMappedPolicy(policyName)
+		if err := sys.store.saveMappedPolicy(accessKey, stsUser, false, mp); err != nil {
+			return err
+		}
+
+		sys.iamUserPolicyMap[accessKey] = mp
+	}
+
 	u := newUserIdentity(cred)
 	if err := sys.store.saveUserIdentity(accessKey, stsUser, u); err != nil {
 		return err
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"strings"
+
+	cid "github.com/ipfs/go-cid"
+	mh "github.com/multiformats/go-multihash"
+)
+
+func parseCID(s string) (string, error) {
+	c, err := cid.Decode(strings.TrimPrefix(s, "/ipfs/"))
+	if err != nil {
+		return "", err
+	}
+
+	prefix := c.Prefix()
+	if prefix.Version == 1 && prefix.Codec == cid.DagProtobuf && prefix.MhType == mh.SHA2_256 && prefix.MhLength == 32 {
+		c = cid.NewCidV0(c.Hash())
+	}
+	return c.String(), nil
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	shell "github.com/ipfs/go-ipfs-api"
+
+	xhttp "github.com/storeros/ipos/cmd/ipos/http"
+)
+
+const (
+	ipfsMetaJSONFile     = "ipos.json"
+	ipfsMetaVersion      = "1.0.0"
+	ipfsMetaObjectPrefix = "meta"
+)
+
+type ipfsMetaV1 struct {
+	Version          string            `json:"version"`
+	CID              string            `json:"cid"`
+	ModTime          time.Time         `json:"mtime"`
+	Meta             map[string]string `json:"meta,omitempty"`
+	Parts            []ObjectPartInfo  `json:"parts,omitempty"`
+	CompressionIndex []byte            `json:"compressionIndex,omitempty"`
+	Pin              *ObjectPinInfo    `json:"pin,omitempty"`
+}
+
+func newIPFSMetaV1() ipfsMetaV1 {
+	return ipfsMetaV1{
+		Version: ipfsMetaVersion,
+	}
+}
+
+func (m ipfsMetaV1) ToObjectInfo(bucket, object string, stat *shell.FilesStatObject) ObjectInfo {
+	if len(m.Meta) == 0 {
+		m.Meta = make(map[string]string)
+	}
+
+	objInfo := ObjectInfo{
+		Bucket:  bucket,
+		Name:    object,
+		ModTime: m.ModTime,
+		Size:    int64(stat.Size),
+		IsDir:   stat.Type == "directory",
+		AccTime: m.ModTime,
+		CID:     stat.Hash,
+	}
+
+	objInfo.ETag = extractETag(m.Meta)
+	objInfo.ContentType = m.Meta["content-type"]
+	objInfo.ContentEncoding = m.Meta["content-encoding"]
+	if storageClass, ok := m.Meta[xhttp.AmzStorageClass]; ok {
+		objInfo.StorageClass = storageClass
+	} else {
+		objInfo.StorageClass = globalIPOSDefaultStorageClass
+	}
+
+	if exp, ok := m.Meta["expires"]; ok {
+		if t, e := time.Parse(http.TimeFormat, exp); e == nil {
+			objInfo.Expires = t.UTC()
+		}
+	}
+
+	objInfo.UserDefined = cleanMetadata(m.Meta)
+	objInfo.UserTags = m.Meta[xhttp.AmzObjectTagging]
+	objInfo.Parts = m.Parts
+	objInfo.CompressionIndex = m.CompressionIndex
+	if m.Pin != nil {
+		objInfo.Pin = *m.Pin
+	}
+
+	return objInfo
+}
+
+func (fs *IPFSObjects) metaDir(bucket, object string) string {
+	return fs.path(iposMetaBucket, pathJoin(ipfsMetaObjectPrefix, bucket, object))
+}
+
+func (fs *IPFSObjects) readMetadata(ctx context.Context, bucket, object string, stat *shell.FilesStatObject, adopt bool) (ipfsMetaV1, error) {
+	m := newIPFSMetaV1()
+	if isIPOSMetaBucketName(bucket) {
+		m.CID = stat.Hash
+		m.Meta = map[string]string{"etag": stat.Hash}
+		return m, nil
+	}
+
+	err := fs.readJSON(ctx, pathJoin(fs.metaDir(bucket, object), ipfsMetaJSONFile), &m)
+	if err != nil && !isIPFSErrNotFound(err) {
+		return m, err
+	}
+
+	if err != nil || m.CID != stat.Hash {
+		// The object was written without going through the gateway, or the
+		// sidecar was left behind by an interrupted write. Adopt the current
+		// content once so that its ETag and modification time stay stable.
+		// Until then it is reported with a zero modification time, callers
+		// holding the write lock are the only ones allowed to adopt.
+		m = newIPFSMetaV1()
+		m.CID = stat.Hash
+		m.Meta = map[string]string{"etag": stat.Hash}
+		if adopt {
+			m.ModTime = UTCNow()
+			if err = fs.writeMetadata(ctx, bucket, object, m); err != nil {
+				return m, err
+			}
+		}
+	}
+	if m.Meta == nil {
+		m.Meta = make(map[string]string)
+	}
+	return m, nil
+}
+
+func (fs *IPFSObjects) writeMetadata(ctx context.Context, bucket, object string, m ipfsMetaV1) error {
+	if isIPOSMetaBucketName(bucket) {
+		return nil
+	}
+	return fs.writeJSON(ctx, pathJoin(fs.metaDir(bucket, object), ipfsMetaJSONFile), m)
+}
+
+func (fs *IPFSObjects) deleteMetadata(ctx context.Context, bucket, object string) error {
+	if isIPOSMetaBucketName(bucket) {
+		return nil
+	}
+
+	err := fs.shell.FilesRm(ctx, fs.metaDir(bucket, object), true)
+	if err != nil && !isIPFSErrNotFound(err) {
+		return err
+	}
+	fs.deleteEmptyParents(ctx, iposMetaBucket, pathJoin(ipfsMetaObjectPrefix, bucket, object))
+	return nil
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type ipfsMetricsTransport struct {
+	transport http.RoundTripper
+	metrics   *Metrics
+}
+
+type countingReadCloser struct {
+	io.ReadCloser
+	countFn func(uint64)
+}
+
+func (c *countingReadCloser) Read(p []byte) (n int, err error) {
+	n, err = c.ReadCloser.Read(p)
+	c.countFn(uint64(n))
+	return n, err
+}
+
+func (t *ipfsMetricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	command := strings.TrimPrefix(req.URL.Path, "/api/v0/")
+
+	if req.Body != nil {
+		req = req.Clone(req.Context())
+		req.Body = &countingReadCloser{ReadCloser: req.Body, countFn: t.metrics.IncBytesSent}
+	}
+
+	t.metrics.IncRequests(req.Method)
+
+	start := time.Now()
+	resp, err := t.transport.RoundTrip(req)
+	t.metrics.ObserveCall(command, time.Since(start).Seconds(), err != nil || resp.StatusCode != http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = &countingReadCloser{ReadCloser: resp.Body, countFn: t.metrics.IncBytesReceived}
+	return resp, nil
+}
+
+func newIPFSHTTPClient(metrics *Metrics) *http.Client {
+	return &http.Client{
+		Transport: &ipfsMetricsTransport{
+			transport: &http.Transport{
+				Proxy:             http.ProxyFromEnvironment,
+				DisableKeepAlives: true,
+			},
+			metrics: metrics,
+		},
+	}
+}
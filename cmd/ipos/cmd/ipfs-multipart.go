@@ -2,33 +2,554 @@ package cmd
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	shell "github.com/ipfs/go-ipfs-api"
+
+	"github.com/storeros/ipos/cmd/ipos/logger"
+)
+
+const (
+	ipfsMultipartMetaFile    = "ipos.json"
+	ipfsMultipartMetaVersion = "1.0.0"
 )
 
+var ipfsTryLockTimeout = newDynamicTimeout(time.Second, time.Second)
+
+type ipfsMultipartMeta struct {
+	Version   string            `json:"version"`
+	Bucket    string            `json:"bucket"`
+	Object    string            `json:"object"`
+	Initiated time.Time         `json:"initiated"`
+	Meta      map[string]string `json:"meta,omitempty"`
+}
+
+func (fs *IPFSObjects) getMultipartSHADir(bucket, object string) string {
+	return getSHA256Hash([]byte(pathJoin(bucket, object)))
+}
+
+func isValidUploadID(uploadID string) bool {
+	id, err := uuid.Parse(uploadID)
+	return err == nil && id.String() == uploadID
+}
+
+func (fs *IPFSObjects) getUploadIDDir(bucket, object, uploadID string) string {
+	return fs.path(iposMetaMultipartBucket, pathJoin(fs.getMultipartSHADir(bucket, object), uploadID))
+}
+
+func (fs *IPFSObjects) encodePartFile(partID int, etag string, actualSize int64, modTime time.Time) string {
+	return fmt.Sprintf("%.5d.%s.%d.%d", partID, etag, actualSize, modTime.UnixNano())
+}
+
+func (fs *IPFSObjects) decodePartFile(name string) (partID int, etag string, actualSize int64, modTime time.Time, err error) {
+	result := strings.Split(name, ".")
+	if len(result) != 4 {
+		return 0, "", 0, modTime, errUnexpected
+	}
+	partID, err = strconv.Atoi(result[0])
+	if err != nil {
+		return 0, "", 0, modTime, errUnexpected
+	}
+	actualSize, err = strconv.ParseInt(result[2], 10, 64)
+	if err != nil {
+		return 0, "", 0, modTime, errUnexpected
+	}
+	nsec, err := strconv.ParseInt(result[3], 10, 64)
+	if err != nil {
+		return 0, "", 0, modTime, errUnexpected
+	}
+	return partID, result[1], actualSize, time.Unix(0, nsec).UTC(), nil
+}
+
+func (fs *IPFSObjects) checkUploadIDExists(ctx context.Context, bucket, object, uploadID string) (meta ipfsMultipartMeta, err error) {
+	if !isValidUploadID(uploadID) {
+		return meta, InvalidUploadID{Bucket: bucket, Object: object, UploadID: uploadID}
+	}
+
+	metaPath := pathJoin(fs.getUploadIDDir(bucket, object, uploadID), ipfsMultipartMetaFile)
+	if err = fs.readJSON(ctx, metaPath, &meta); err != nil {
+		if isIPFSErrNotFound(err) {
+			return meta, InvalidUploadID{Bucket: bucket, Object: object, UploadID: uploadID}
+		}
+		return meta, fs.ipfsToObjectError(err, bucket, object)
+	}
+	return meta, nil
+}
+
+func (fs *IPFSObjects) removeUploadIDDir(ctx context.Context, bucket, object, uploadID string) error {
+	if err := fs.shell.FilesRm(ctx, fs.getUploadIDDir(bucket, object, uploadID), true); err != nil {
+		return err
+	}
+
+	shaDir := fs.path(iposMetaMultipartBucket, fs.getMultipartSHADir(bucket, object))
+	if entries, err := fs.shell.FilesLs(ctx, shaDir); err == nil && len(entries) == 0 {
+		return fs.shell.FilesRm(ctx, shaDir, true)
+	}
+	return nil
+}
+
 func (fs *IPFSObjects) ListMultipartUploads(ctx context.Context, bucket, object, keyMarker, uploadIDMarker, delimiter string, maxUploads int) (result ListMultipartsInfo, e error) {
-	return
+	if err := checkListMultipartArgs(ctx, bucket, object, keyMarker, uploadIDMarker, delimiter, fs); err != nil {
+		return result, toObjectErr(err)
+	}
+
+	result.MaxUploads = maxUploads
+	result.KeyMarker = keyMarker
+	result.Prefix = object
+	result.Delimiter = delimiter
+	result.NextKeyMarker = object
+	result.UploadIDMarker = uploadIDMarker
+
+	shaDir := fs.path(iposMetaMultipartBucket, fs.getMultipartSHADir(bucket, object))
+	entries, err := fs.shell.FilesLs(ctx, shaDir)
+	if err != nil {
+		if isIPFSErrNotFound(err) {
+			result.IsTruncated = false
+			return result, nil
+		}
+		return result, fs.ipfsToObjectError(err, bucket, object)
+	}
+
+	var uploads []MultipartInfo
+	for _, entry := range entries {
+		var meta ipfsMultipartMeta
+		if err = fs.readJSON(ctx, pathJoin(shaDir, entry.Name, ipfsMultipartMetaFile), &meta); err != nil {
+			continue
+		}
+		uploads = append(uploads, MultipartInfo{
+			Object:    object,
+			UploadID:  entry.Name,
+			Initiated: meta.Initiated,
+		})
+	}
+
+	sort.Slice(uploads, func(i int, j int) bool {
+		return uploads[i].Initiated.Before(uploads[j].Initiated)
+	})
+
+	uploadIndex := 0
+	if uploadIDMarker != "" {
+		for uploadIndex < len(uploads) {
+			if uploads[uploadIndex].UploadID == uploadIDMarker {
+				uploadIndex++
+				break
+			}
+			uploadIndex++
+		}
+	}
+	for uploadIndex < len(uploads) {
+		result.Uploads = append(result.Uploads, uploads[uploadIndex])
+		result.NextUploadIDMarker = uploads[uploadIndex].UploadID
+		uploadIndex++
+		if len(result.Uploads) == maxUploads {
+			break
+		}
+	}
+
+	result.IsTruncated = uploadIndex < len(uploads)
+
+	if !result.IsTruncated {
+		result.NextKeyMarker = ""
+		result.NextUploadIDMarker = ""
+	}
+
+	return result, nil
 }
 
 func (fs *IPFSObjects) NewMultipartUpload(ctx context.Context, bucket, object string, opts ObjectOptions) (string, error) {
-	return "", nil
+	if err := checkNewMultipartArgs(ctx, bucket, object, fs); err != nil {
+		return "", toObjectErr(err, bucket)
+	}
+
+	uploadID := mustGetUUID()
+	uploadIDDir := fs.getUploadIDDir(bucket, object, uploadID)
+
+	if err := fs.shell.FilesMkdir(ctx, uploadIDDir, shell.FilesMkdir.Parents(true)); err != nil {
+		return "", fs.ipfsToObjectError(err, bucket, object)
+	}
+
+	meta := ipfsMultipartMeta{
+		Version:   ipfsMultipartMetaVersion,
+		Bucket:    bucket,
+		Object:    object,
+		Initiated: UTCNow(),
+		Meta:      opts.UserDefined,
+	}
+	if err := fs.writeJSON(ctx, pathJoin(uploadIDDir, ipfsMultipartMetaFile), meta); err != nil {
+		return "", fs.ipfsToObjectError(err, bucket, object)
+	}
+
+	return uploadID, nil
 }
 
 func (fs *IPFSObjects) CopyObjectPart(ctx context.Context, srcBucket, srcObject, dstBucket, dstObject, uploadID string, partID int,
 	startOffset int64, length int64, srcInfo ObjectInfo, srcOpts, dstOpts ObjectOptions) (pi PartInfo, e error) {
-	return
+	if !isValidUploadID(uploadID) {
+		return pi, InvalidUploadID{Bucket: dstBucket, Object: dstObject, UploadID: uploadID}
+	}
+
+	if err := checkNewMultipartArgs(ctx, srcBucket, srcObject, fs); err != nil {
+		return pi, toObjectErr(err)
+	}
+
+	partInfo, err := fs.PutObjectPart(ctx, dstBucket, dstObject, uploadID, partID, srcInfo.PutObjReader, dstOpts)
+	if err != nil {
+		return pi, toObjectErr(err, dstBucket, dstObject)
+	}
+
+	return partInfo, nil
 }
 
 func (fs *IPFSObjects) PutObjectPart(ctx context.Context, bucket, object, uploadID string, partID int, r *PutObjReader, opts ObjectOptions) (pi PartInfo, e error) {
-	return
+	if !isValidUploadID(uploadID) {
+		return pi, InvalidUploadID{Bucket: bucket, Object: object, UploadID: uploadID}
+	}
+
+	data := r.Reader
+	if err := checkPutObjectPartArgs(ctx, bucket, object, fs); err != nil {
+		return pi, toObjectErr(err, bucket)
+	}
+
+	if data.Size() < -1 {
+		logger.LogIf(ctx, errInvalidArgument, logger.Application)
+		return pi, toObjectErr(errInvalidArgument)
+	}
+
+	uploadIDLock := fs.NewNSLock(ctx, iposMetaMultipartBucket, pathJoin(fs.getMultipartSHADir(bucket, object), uploadID))
+	if err := uploadIDLock.GetRLock(globalOperationTimeout); err != nil {
+		return pi, err
+	}
+	defer uploadIDLock.RUnlock()
+	ctx = uploadIDLock.Context()
+
+	if _, err := fs.checkUploadIDExists(ctx, bucket, object, uploadID); err != nil {
+		return pi, err
+	}
+
+	uploadIDDir := fs.getUploadIDDir(bucket, object, uploadID)
+	tmpPartPath := pathJoin(uploadIDDir, mustGetUUID())
+	err := fs.shell.FilesWrite(ctx, tmpPartPath, data, shell.FilesWrite.Create(true), shell.FilesWrite.Truncate(true))
+	if err != nil {
+		fs.shell.FilesRm(ctx, tmpPartPath, true)
+		if verr := data.Verify(); verr != nil {
+			return pi, verr
+		}
+		return pi, fs.ipfsToObjectError(err, bucket, object)
+	}
+
+	stat, err := fs.shell.FilesStat(ctx, tmpPartPath)
+	if err != nil {
+		fs.shell.FilesRm(ctx, tmpPartPath, true)
+		return pi, fs.ipfsToObjectError(err, bucket, object)
+	}
+	if data.Size() >= 0 && int64(stat.Size) < data.Size() {
+		fs.shell.FilesRm(ctx, tmpPartPath, true)
+		return pi, IncompleteBody{Bucket: bucket, Object: object}
+	}
+
+	entries, err := fs.shell.FilesLs(ctx, uploadIDDir)
+	if err != nil {
+		fs.shell.FilesRm(ctx, tmpPartPath, true)
+		return pi, fs.ipfsToObjectError(err, bucket, object)
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name, fmt.Sprintf("%.5d.", partID)) {
+			fs.shell.FilesRm(ctx, pathJoin(uploadIDDir, entry.Name), true)
+		}
+	}
+
+	etag := r.MD5CurrentHexString()
+
+	modTime := UTCNow()
+	partPath := pathJoin(uploadIDDir, fs.encodePartFile(partID, etag, data.ActualSize(), modTime))
+	if err = fs.shell.FilesMv(ctx, tmpPartPath, partPath); err != nil {
+		fs.shell.FilesRm(ctx, tmpPartPath, true)
+		return pi, fs.ipfsToObjectError(err, bucket, object)
+	}
+
+	return PartInfo{
+		PartNumber:   partID,
+		LastModified: modTime,
+		ETag:         etag,
+		Size:         int64(stat.Size),
+		ActualSize:   data.ActualSize(),
+	}, nil
+}
+
+func (fs *IPFSObjects) listParts(ctx context.Context, uploadIDDir string) (map[int]PartInfo, map[int]string, error) {
+	entries, err := fs.shell.FilesLs(ctx, uploadIDDir, shell.FilesLs.Stat(true))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	parts := make(map[int]PartInfo)
+	partFiles := make(map[int]string)
+	for _, entry := range entries {
+		if entry.Name == ipfsMultipartMetaFile {
+			continue
+		}
+		partNumber, etag, actualSize, modTime, derr := fs.decodePartFile(entry.Name)
+		if derr != nil {
+			continue
+		}
+		parts[partNumber] = PartInfo{
+			PartNumber:   partNumber,
+			LastModified: modTime,
+			ETag:         etag,
+			Size:         int64(entry.Size),
+			ActualSize:   actualSize,
+		}
+		partFiles[partNumber] = entry.Name
+	}
+	return parts, partFiles, nil
 }
 
 func (fs *IPFSObjects) ListObjectParts(ctx context.Context, bucket, object, uploadID string, partNumberMarker, maxParts int, opts ObjectOptions) (result ListPartsInfo, e error) {
-	return
+	if !isValidUploadID(uploadID) {
+		return result, InvalidUploadID{Bucket: bucket, Object: object, UploadID: uploadID}
+	}
+
+	if err := checkListPartsArgs(ctx, bucket, object, fs); err != nil {
+		return result, toObjectErr(err)
+	}
+	result.Bucket = bucket
+	result.Object = object
+	result.UploadID = uploadID
+	result.MaxParts = maxParts
+	result.PartNumberMarker = partNumberMarker
+
+	meta, err := fs.checkUploadIDExists(ctx, bucket, object, uploadID)
+	if err != nil {
+		return result, err
+	}
+
+	parts, _, err := fs.listParts(ctx, fs.getUploadIDDir(bucket, object, uploadID))
+	if err != nil {
+		return result, fs.ipfsToObjectError(err, bucket, object)
+	}
+
+	var partNums []int
+	for partNumber := range parts {
+		partNums = append(partNums, partNumber)
+	}
+	sort.Ints(partNums)
+
+	var partsInfo []PartInfo
+	for _, partNumber := range partNums {
+		if partNumber <= partNumberMarker {
+			continue
+		}
+		partsInfo = append(partsInfo, parts[partNumber])
+	}
+
+	if len(partsInfo) > maxParts {
+		result.IsTruncated = true
+		partsInfo = partsInfo[:maxParts]
+	}
+	result.Parts = partsInfo
+	if len(partsInfo) > 0 {
+		result.NextPartNumberMarker = partsInfo[len(partsInfo)-1].PartNumber
+	}
+	result.UserDefined = meta.Meta
+
+	return result, nil
 }
 
 func (fs *IPFSObjects) CompleteMultipartUpload(ctx context.Context, bucket string, object string, uploadID string, parts []CompletePart, opts ObjectOptions) (oi ObjectInfo, e error) {
-	return
+	if !isValidUploadID(uploadID) {
+		return oi, InvalidUploadID{Bucket: bucket, Object: object, UploadID: uploadID}
+	}
+
+	if err := checkCompleteMultipartArgs(ctx, bucket, object, fs); err != nil {
+		return oi, toObjectErr(err)
+	}
+
+	uploadIDLock := fs.NewNSLock(ctx, iposMetaMultipartBucket, pathJoin(fs.getMultipartSHADir(bucket, object), uploadID))
+	if err := uploadIDLock.GetLock(globalOperationTimeout); err != nil {
+		return oi, err
+	}
+	defer uploadIDLock.Unlock()
+	ctx = uploadIDLock.Context()
+
+	objectLock := fs.NewNSLock(ctx, bucket, object)
+	if err := objectLock.GetLock(globalObjectTimeout); err != nil {
+		logger.LogIf(ctx, err)
+		return oi, err
+	}
+	defer objectLock.Unlock()
+	ctx = objectLock.Context()
+
+	meta, err := fs.checkUploadIDExists(ctx, bucket, object, uploadID)
+	if err != nil {
+		return oi, err
+	}
+
+	uploadIDDir := fs.getUploadIDDir(bucket, object, uploadID)
+	partInfos, partFiles, err := fs.listParts(ctx, uploadIDDir)
+	if err != nil {
+		return oi, fs.ipfsToObjectError(err, bucket, object)
+	}
+
+	s3MD5 := getCompleteMultipartMD5(parts)
+
+	for i, part := range parts {
+		partInfo, ok := partInfos[part.PartNumber]
+		if !ok || partInfo.ETag != canonicalizeETag(part.ETag) {
+			return oi, InvalidPart{
+				PartNumber: part.PartNumber,
+				ExpETag:    partInfo.ETag,
+				GotETag:    part.ETag,
+			}
+		}
+
+		if i < len(parts)-1 && !isMinAllowedPartSize(partInfo.ActualSize) {
+			return oi, PartTooSmall{
+				PartNumber: part.PartNumber,
+				PartSize:   partInfo.ActualSize,
+				PartETag:   part.ETag,
+			}
+		}
+	}
+
+	tmpPath := fs.tmpPath(mustGetUUID())
+	defer fs.shell.FilesRm(ctx, tmpPath, true)
+
+	var offset int64
+	for _, part := range parts {
+		reader, err := fs.shell.FilesRead(ctx, pathJoin(uploadIDDir, partFiles[part.PartNumber]))
+		if err != nil {
+			return oi, fs.ipfsToObjectError(err, bucket, object)
+		}
+		err = fs.shell.FilesWrite(ctx, tmpPath, reader, shell.FilesWrite.Create(true), shell.FilesWrite.Offset(offset))
+		reader.Close()
+		if err != nil {
+			return oi, fs.ipfsToObjectError(err, bucket, object)
+		}
+		offset += partInfos[part.PartNumber].Size
+	}
+
+	stat, err := fs.shell.FilesStat(ctx, tmpPath)
+	if err != nil {
+		return oi, fs.ipfsToObjectError(err, bucket, object)
+	}
+
+	fsMeta := newIPFSMetaV1()
+	fsMeta.CID = stat.Hash
+	fsMeta.ModTime = UTCNow()
+	fsMeta.Meta = make(map[string]string, len(meta.Meta)+1)
+	for k, v := range meta.Meta {
+		fsMeta.Meta[k] = v
+	}
+	fsMeta.Meta["etag"] = s3MD5
+	fsMeta.Parts = make([]ObjectPartInfo, len(parts))
+	var objectActualSize int64
+	for i, part := range parts {
+		fsMeta.Parts[i] = ObjectPartInfo{
+			Number:     part.PartNumber,
+			Size:       partInfos[part.PartNumber].Size,
+			ActualSize: partInfos[part.PartNumber].ActualSize,
+		}
+		objectActualSize += partInfos[part.PartNumber].ActualSize
+	}
+	if _, ok := fsMeta.Meta[ReservedMetadataPrefix+"compression"]; ok {
+		fsMeta.Meta[ReservedMetadataPrefix+"actual-size"] = strconv.FormatInt(objectActualSize, 10)
+	}
+
+	oldPin := fs.readPinInfo(ctx, bucket, object)
+	fsMeta.Pin = fs.pinObject(ctx, bucket, object, stat.Hash)
+
+	if err = fs.commitObject(ctx, tmpPath, bucket, object, fsMeta); err != nil {
+		fs.unpinObject(ctx, bucket, object, fsMeta.Pin, oldPin)
+		return oi, fs.ipfsToObjectError(err, bucket, object)
+	}
+	fs.unpinObject(ctx, bucket, object, oldPin, fsMeta.Pin)
+
+	if err = fs.removeUploadIDDir(ctx, bucket, object, uploadID); err != nil {
+		logger.LogIf(ctx, err)
+	}
+
+	return fsMeta.ToObjectInfo(bucket, object, stat), nil
 }
 
 func (fs *IPFSObjects) AbortMultipartUpload(ctx context.Context, bucket, object, uploadID string) error {
+	if !isValidUploadID(uploadID) {
+		return InvalidUploadID{Bucket: bucket, Object: object, UploadID: uploadID}
+	}
+
+	if err := checkAbortMultipartArgs(ctx, bucket, object, fs); err != nil {
+		return err
+	}
+
+	uploadIDLock := fs.NewNSLock(ctx, iposMetaMultipartBucket, pathJoin(fs.getMultipartSHADir(bucket, object), uploadID))
+	if err := uploadIDLock.GetLock(globalOperationTimeout); err != nil {
+		return err
+	}
+	defer uploadIDLock.Unlock()
+	ctx = uploadIDLock.Context()
+
+	if _, err := fs.checkUploadIDExists(ctx, bucket, object, uploadID); err != nil {
+		return err
+	}
+
+	if err := fs.removeUploadIDDir(ctx, bucket, object, uploadID); err != nil {
+		return fs.ipfsToObjectError(err, bucket, object)
+	}
+
 	return nil
 }
+
+func (fs *IPFSObjects) cleanupStaleMultipartUploads(ctx context.Context, cleanupInterval, expiry time.Duration) {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now()
+			shaDirs, err := fs.shell.FilesLs(ctx, fs.path(iposMetaMultipartBucket))
+			if err != nil {
+				continue
+			}
+			for _, shaDir := range shaDirs {
+				uploadIDDirs, err := fs.shell.FilesLs(ctx, fs.path(iposMetaMultipartBucket, shaDir.Name))
+				if err != nil {
+					continue
+				}
+				for _, uploadIDDir := range uploadIDDirs {
+					var meta ipfsMultipartMeta
+					metaPath := fs.path(iposMetaMultipartBucket, pathJoin(shaDir.Name, uploadIDDir.Name, ipfsMultipartMetaFile))
+					if err = fs.readJSON(ctx, metaPath, &meta); err != nil {
+						continue
+					}
+					if now.Sub(meta.Initiated) > expiry {
+						logger.LogIf(ctx, fs.removeStaleUpload(ctx, meta.Bucket, meta.Object, uploadIDDir.Name))
+					}
+				}
+			}
+		}
+	}
+}
+
+func (fs *IPFSObjects) removeStaleUpload(ctx context.Context, bucket, object, uploadID string) error {
+	// Skip uploads that are still being written to or completed, they are
+	// picked up again on the next run if they are really abandoned.
+	uploadIDLock := fs.NewNSLock(ctx, iposMetaMultipartBucket, pathJoin(fs.getMultipartSHADir(bucket, object), uploadID))
+	if err := uploadIDLock.GetLock(ipfsTryLockTimeout); err != nil {
+		return nil
+	}
+	defer uploadIDLock.Unlock()
+	ctx = uploadIDLock.Context()
+
+	err := fs.removeUploadIDDir(ctx, bucket, object, uploadID)
+	if isIPFSErrNotFound(err) {
+		return nil
+	}
+	return err
+}
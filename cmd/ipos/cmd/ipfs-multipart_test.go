@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+)
+
+func TestIsValidUploadID(t *testing.T) {
+	testCases := []struct {
+		uploadID string
+		valid    bool
+	}{
+		{mustGetUUID(), true},
+		{"6d0ba2b4-2dc4-4a5b-9e43-9f2a1b1f1c0e", true},
+		{"", false},
+		{"not-a-uuid", false},
+		{"../../buckets/bucket/object", false},
+		{"6d0ba2b4-2dc4-4a5b-9e43-9f2a1b1f1c0e/..", false},
+		{"6d0ba2b42dc44a5b9e439f2a1b1f1c0e", false},
+		{"{6d0ba2b4-2dc4-4a5b-9e43-9f2a1b1f1c0e}", false},
+		{"urn:uuid:6d0ba2b4-2dc4-4a5b-9e43-9f2a1b1f1c0e", false},
+		{"6D0BA2B4-2DC4-4A5B-9E43-9F2A1B1F1C0E", false},
+	}
+
+	for i, testCase := range testCases {
+		if got := isValidUploadID(testCase.uploadID); got != testCase.valid {
+			t.Errorf("Test %d: expected %v for %q, got %v", i+1, testCase.valid, testCase.uploadID, got)
+		}
+	}
+}
+
+func TestIPFSMultipartRejectsInvalidUploadID(t *testing.T) {
+	fs := &IPFSObjects{}
+	ctx := context.Background()
+	uploadID := "../../buckets/bucket/object"
+
+	testCases := []struct {
+		name string
+		fn   func() error
+	}{
+		{"PutObjectPart", func() error {
+			_, err := fs.PutObjectPart(ctx, "bucket", "object", uploadID, 1, nil, ObjectOptions{})
+			return err
+		}},
+		{"CopyObjectPart", func() error {
+			_, err := fs.CopyObjectPart(ctx, "bucket", "src", "bucket", "object", uploadID, 1, 0, -1, ObjectInfo{}, ObjectOptions{}, ObjectOptions{})
+			return err
+		}},
+		{"ListObjectParts", func() error {
+			_, err := fs.ListObjectParts(ctx, "bucket", "object", uploadID, 0, 1000, ObjectOptions{})
+			return err
+		}},
+		{"CompleteMultipartUpload", func() error {
+			_, err := fs.CompleteMultipartUpload(ctx, "bucket", "object", uploadID, nil, ObjectOptions{})
+			return err
+		}},
+		{"AbortMultipartUpload", func() error {
+			return fs.AbortMultipartUpload(ctx, "bucket", "object", uploadID)
+		}},
+		{"checkUploadIDExists", func() error {
+			_, err := fs.checkUploadIDExists(ctx, "bucket", "object", uploadID)
+			return err
+		}},
+	}
+
+	for _, testCase := range testCases {
+		if _, ok := testCase.fn().(InvalidUploadID); !ok {
+			t.Errorf("%s: expected InvalidUploadID for %q", testCase.name, uploadID)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	shell "github.com/ipfs/go-ipfs-api"
+
+	"github.com/storeros/ipos/cmd/ipos/logger"
+	"github.com/storeros/ipos/pkg/bucket/pinning"
+)
+
+func isIPFSErrNotPinned(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "not pinned")
+}
+
+const (
+	ipfsPinRefsPrefix      = "pins"
+	remotePinQueueCapacity = 10000
+)
+
+type remotePinJob struct {
+	bucket    string
+	object    string
+	cid       string
+	requestID string
+}
+
+func (fs *IPFSObjects) pinObject(ctx context.Context, bucket, object, cid string) *ObjectPinInfo {
+	if globalBucketPinningSys == nil || isIPOSMetaBucketName(bucket) {
+		return nil
+	}
+
+	config, ok := globalBucketPinningSys.Get(bucket)
+	if !ok {
+		return nil
+	}
+
+	pin := &ObjectPinInfo{CID: cid}
+	if config.Local {
+		pin.Local = pinning.StatusPinned
+		if err := fs.pinLocal(ctx, bucket, object, cid); err != nil {
+			logger.LogIf(ctx, err)
+			pin.Local = pinning.StatusFailed
+		}
+	}
+	if config.Remote {
+		pin.Remote = pinning.StatusFailed
+		if globalPinningService == nil || !globalPinningService.Enabled {
+			logger.LogIf(ctx, errPinningServiceNotConfigured)
+		} else if fs.queueRemotePinJob(remotePinJob{bucket: bucket, object: object, cid: cid}) {
+			pin.Remote = pinning.StatusQueued
+		} else {
+			logger.LogIf(ctx, errRemotePinQueueFull)
+		}
+	}
+	return pin
+}
+
+func (fs *IPFSObjects) unpinObject(ctx context.Context, bucket, object string, pin, keep *ObjectPinInfo) {
+	if pin == nil {
+		return
+	}
+
+	if pin.Local != "" && (keep == nil || keep.CID != pin.CID || keep.Local != pinning.StatusPinned) {
+		if err := fs.unpinLocal(ctx, bucket, object, pin.CID); err != nil {
+			logger.LogIf(ctx, err)
+		}
+	}
+	if pin.RequestID != "" && (keep == nil || keep.RequestID != pin.RequestID) {
+		if globalPinningService == nil || !globalPinningService.Enabled {
+			logger.LogIf(ctx, errPinningServiceNotConfigured)
+		} else if !fs.queueRemotePinJob(remotePinJob{bucket: bucket, object: object, cid: pin.CID, requestID: pin.RequestID}) {
+			logger.LogIf(ctx, fmt.Errorf("%w, remote pin request %s is left behind", errRemotePinQueueFull, pin.RequestID))
+		}
+	}
+}
+
+func (fs *IPFSObjects) pinRefsDir(cid string) string {
+	return fs.path(iposMetaBucket, pathJoin(ipfsPinRefsPrefix, cid))
+}
+
+func (fs *IPFSObjects) pinLocal(ctx context.Context, bucket, object, cid string) error {
+	cidLock := fs.NewNSLock(ctx, iposMetaBucket, pathJoin(ipfsPinRefsPrefix, cid))
+	if err := cidLock.GetLock(globalObjectTimeout); err != nil {
+		return err
+	}
+	defer cidLock.Unlock()
+	ctx = cidLock.Context()
+
+	refsDir := fs.pinRefsDir(cid)
+	if err := fs.shell.FilesMkdir(ctx, refsDir, shell.FilesMkdir.Parents(true)); err != nil {
+		return err
+	}
+	err := fs.shell.FilesWrite(ctx, pathJoin(refsDir, getSHA256Hash([]byte(pathJoin(bucket, object)))),
+		strings.NewReader(pathJoin(bucket, object)), shell.FilesWrite.Create(true), shell.FilesWrite.Truncate(true))
+	if err != nil {
+		return err
+	}
+	return fs.shell.Pin(cid)
+}
+
+func (fs *IPFSObjects) unpinLocal(ctx context.Context, bucket, object, cid string) error {
+	cidLock := fs.NewNSLock(ctx, iposMetaBucket, pathJoin(ipfsPinRefsPrefix, cid))
+	if err := cidLock.GetLock(globalObjectTimeout); err != nil {
+		return err
+	}
+	defer cidLock.Unlock()
+	ctx = cidLock.Context()
+
+	refsDir := fs.pinRefsDir(cid)
+	err := fs.shell.FilesRm(ctx, pathJoin(refsDir, getSHA256Hash([]byte(pathJoin(bucket, object)))), true)
+	if err != nil && !isIPFSErrNotFound(err) {
+		return err
+	}
+
+	// Objects with identical content share a single pin, keep it as long
+	// as any of them still references the CID.
+	refs, err := fs.shell.FilesLs(ctx, refsDir)
+	if err != nil && !isIPFSErrNotFound(err) {
+		return err
+	}
+	if len(refs) > 0 {
+		return nil
+	}
+	if err = fs.shell.FilesRm(ctx, refsDir, true); err != nil && !isIPFSErrNotFound(err) {
+		return err
+	}
+
+	if err = fs.shell.Unpin(cid); err != nil && !isIPFSErrNotPinned(err) {
+		return err
+	}
+	return nil
+}
+
+func (fs *IPFSObjects) queueRemotePinJob(job remotePinJob) bool {
+	select {
+	case fs.remotePinQueue <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+func (fs *IPFSObjects) processRemotePinJobs(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-fs.remotePinQueue:
+			fs.runRemotePinJob(ctx, job)
+		}
+	}
+}
+
+func (fs *IPFSObjects) runRemotePinJob(ctx context.Context, job remotePinJob) {
+	if job.requestID != "" {
+		logger.LogIf(ctx, globalPinningService.Unpin(ctx, job.requestID))
+		return
+	}
+
+	pin := ObjectPinInfo{CID: job.cid, Remote: pinning.StatusFailed}
+	status, err := globalPinningService.Pin(ctx, job.cid, pathJoin(job.bucket, job.object))
+	if err != nil {
+		logger.LogIf(ctx, err)
+	} else {
+		pin.Remote = status.Status
+		pin.RequestID = status.RequestID
+	}
+
+	recorded, err := fs.recordRemotePin(ctx, job, pin)
+	logger.LogIf(ctx, err)
+
+	// Nothing refers to the remote pin anymore, the object was removed or
+	// replaced while the request was queued.
+	if !recorded && pin.RequestID != "" {
+		logger.LogIf(ctx, globalPinningService.Unpin(ctx, pin.RequestID))
+	}
+}
+
+func (fs *IPFSObjects) recordRemotePin(ctx context.Context, job remotePinJob, pin ObjectPinInfo) (bool, error) {
+	objectLock := fs.NewNSLock(ctx, job.bucket, job.object)
+	if err := objectLock.GetLock(globalObjectTimeout); err != nil {
+		return false, err
+	}
+	defer objectLock.Unlock()
+	ctx = objectLock.Context()
+
+	m := newIPFSMetaV1()
+	metaFile := pathJoin(fs.metaDir(job.bucket, job.object), ipfsMetaJSONFile)
+	if err := fs.readJSON(ctx, metaFile, &m); err != nil {
+		if isIPFSErrNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if m.Pin == nil || m.Pin.CID != job.cid || m.Pin.Remote != pinning.StatusQueued || m.Pin.RequestID != "" {
+		return false, nil
+	}
+
+	m.Pin.Remote = pin.Remote
+	m.Pin.RequestID = pin.RequestID
+	if err := fs.writeJSON(ctx, metaFile, m); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (fs *IPFSObjects) readPinInfo(ctx context.Context, bucket, object string) *ObjectPinInfo {
+	if isIPOSMetaBucketName(bucket) {
+		return nil
+	}
+
+	m := newIPFSMetaV1()
+	if err := fs.readJSON(ctx, pathJoin(fs.metaDir(bucket, object), ipfsMetaJSONFile), &m); err != nil {
+		if !isIPFSErrNotFound(err) {
+			logger.LogIf(ctx, err)
+		}
+		return nil
+	}
+	return m.Pin
+}
+
+func (fs *IPFSObjects) unpinPrefix(ctx context.Context, bucket, prefix string) {
+	entries, err := fs.shell.FilesLs(ctx, fs.metaDir(bucket, prefix), shell.FilesLs.Stat(true))
+	if err != nil {
+		if !isIPFSErrNotFound(err) {
+			logger.LogIf(ctx, err)
+		}
+		return
+	}
+
+	for _, entry := range entries {
+		switch {
+		case entry.Name == ipfsMetaJSONFile && entry.Type != ipfsEntryTypeDirectory:
+			fs.unpinObject(ctx, bucket, prefix, fs.readPinInfo(ctx, bucket, prefix), nil)
+		case entry.Type == ipfsEntryTypeDirectory:
+			fs.unpinPrefix(ctx, bucket, pathJoin(prefix, entry.Name))
+		}
+	}
+}
+
+func (fs *IPFSObjects) GetObjectPinInfo(ctx context.Context, bucket, object string) (pin ObjectPinInfo, err error) {
+	if _, err = fs.shell.FilesStat(ctx, fs.path(bucket)); err != nil {
+		return pin, fs.ipfsToObjectError(err, bucket)
+	}
+
+	// The node and the pinning service can be slow, only the final status
+	// update below runs under the object lock.
+	objInfo, err := fs.getObjectInfoWithLock(ctx, bucket, object)
+	if err != nil {
+		return pin, err
+	}
+	if objInfo.IsDir {
+		return pin, ObjectNotFound{Bucket: bucket, Object: object}
+	}
+
+	pin = objInfo.Pin
+	if pin.CID == "" {
+		return pin, nil
+	}
+
+	if pin.Local != "" {
+		var raw struct {
+			Keys map[string]struct{ Type string }
+		}
+		err = fs.shell.Request("pin/ls", pin.CID).Option("type", "recursive").Exec(ctx, &raw)
+		switch {
+		case err == nil:
+			pin.Local = pinning.StatusPinned
+		case isIPFSErrNotPinned(err):
+			pin.Local = pinning.StatusUnpinned
+		default:
+			return pin, fs.ipfsToObjectError(err, bucket, object)
+		}
+	}
+
+	if pin.RequestID != "" && globalPinningService != nil && globalPinningService.Enabled {
+		status, err := globalPinningService.GetPin(ctx, pin.RequestID)
+		if err != nil {
+			return pin, err
+		}
+		pin.Remote = status.Status
+	}
+
+	if pin != objInfo.Pin {
+		logger.LogIf(ctx, fs.updatePinStatus(ctx, bucket, object, pin))
+	}
+
+	return pin, nil
+}
+
+func (fs *IPFSObjects) updatePinStatus(ctx context.Context, bucket, object string, pin ObjectPinInfo) error {
+	objectLock := fs.NewNSLock(ctx, bucket, object)
+	if err := objectLock.GetLock(globalObjectTimeout); err != nil {
+		return err
+	}
+	defer objectLock.Unlock()
+	ctx = objectLock.Context()
+
+	// The object may have been replaced while the status was queried.
+	m := newIPFSMetaV1()
+	metaFile := pathJoin(fs.metaDir(bucket, object), ipfsMetaJSONFile)
+	err := fs.readJSON(ctx, metaFile, &m)
+	if err != nil {
+		if isIPFSErrNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if m.Pin == nil || m.Pin.CID != pin.CID || m.Pin.RequestID != pin.RequestID {
+		return nil
+	}
+
+	m.Pin = &pin
+	return fs.writeJSON(ctx, metaFile, m)
+}
@@ -1,43 +1,71 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
+	"crypto/md5"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
+	pathutil "path"
 	"strings"
 	"time"
 
 	shell "github.com/ipfs/go-ipfs-api"
+	jsoniter "github.com/json-iterator/go"
 
+	xhttp "github.com/storeros/ipos/cmd/ipos/http"
 	"github.com/storeros/ipos/cmd/ipos/logger"
 	bucketsse "github.com/storeros/ipos/pkg/bucket/encryption"
 	"github.com/storeros/ipos/pkg/bucket/lifecycle"
 	"github.com/storeros/ipos/pkg/bucket/object/tagging"
+	"github.com/storeros/ipos/pkg/bucket/pinning"
 	"github.com/storeros/ipos/pkg/bucket/policy"
+	"github.com/storeros/ipos/pkg/dsync"
 	"github.com/storeros/ipos/pkg/madmin"
 	"github.com/storeros/ipos/pkg/s3utils"
 )
 
-func NewIPFSObjectLayer(host string) (ObjectLayer, error) {
-	s := shell.NewShell(host)
+const (
+	ipfsEntryTypeDirectory = 1
+)
+
+func NewIPFSObjectLayer(host, nodeID string) (ObjectLayer, error) {
+	metrics := NewMetrics()
+	s := shell.NewShellWithClient(host, newIPFSHTTPClient(metrics))
 
 	ipfs := IPFSObjects{
-		shell:    s,
-		listPool: NewTreeWalkPool(globalLookupTimeout),
+		shell:          s,
+		nodeID:         nodeID,
+		metrics:        metrics,
+		nsMutex:        newNSLock(globalIsDistributed),
+		listPool:       NewTreeWalkPool(globalLookupTimeout),
+		remotePinQueue: make(chan remotePinJob, remotePinQueueCapacity),
 	}
 
 	if err := ipfs.initMetaVolumeFS(); err != nil {
 		return nil, err
 	}
 
+	go ipfs.cleanupStaleMultipartUploads(GlobalContext, globalMultipartCleanupInterval, globalMultipartExpiry)
+	go ipfs.processRemotePinJobs(GlobalContext)
+
 	return &ipfs, nil
 }
 
 type IPFSObjects struct {
 	shell *shell.Shell
 
+	nodeID string
+
+	nsMutex *nsLockMap
+
 	listPool *TreeWalkPool
+
+	metrics *Metrics
+
+	remotePinQueue chan remotePinJob
 }
 
 func (fs *IPFSObjects) ipfsToObjectError(err error, params ...string) error {
@@ -92,32 +120,265 @@ func (fs *IPFSObjects) path(params ...string) string {
 	}
 }
 
+func isIPFSErrNotFound(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "file does not exist")
+}
+
+func (fs *IPFSObjects) tmpPath(name string) string {
+	return fs.path(iposMetaTmpBucket, pathJoin(fs.nodeID, name))
+}
+
+func (fs *IPFSObjects) readJSON(ctx context.Context, path string, v interface{}) error {
+	reader, err := fs.shell.FilesRead(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	return json.NewDecoder(reader).Decode(v)
+}
+
+func (fs *IPFSObjects) md5Hash(ctx context.Context, path string) (string, error) {
+	reader, err := fs.shell.FilesRead(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	hash := md5.New()
+	if _, err = io.Copy(hash, reader); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (fs *IPFSObjects) writeJSON(ctx context.Context, path string, v interface{}) error {
+	var json = jsoniter.ConfigCompatibleWithStandardLibrary
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	tmpPath := fs.tmpPath(mustGetUUID())
+	defer fs.shell.FilesRm(ctx, tmpPath, true)
+
+	err = fs.shell.FilesWrite(ctx, tmpPath, bytes.NewReader(data),
+		shell.FilesWrite.Create(true), shell.FilesWrite.Truncate(true))
+	if err != nil {
+		return err
+	}
+
+	return fs.renameAll(ctx, tmpPath, path)
+}
+
+func (fs *IPFSObjects) renameAll(ctx context.Context, srcPath, dstPath string) error {
+	if err := fs.shell.FilesMkdir(ctx, pathutil.Dir(dstPath), shell.FilesMkdir.Parents(true)); err != nil {
+		return err
+	}
+
+	backupPath := fs.tmpPath(mustGetUUID())
+	err := fs.shell.FilesMv(ctx, dstPath, backupPath)
+	if err != nil && !isIPFSErrNotFound(err) {
+		return err
+	}
+	hasBackup := err == nil
+
+	if err = fs.shell.FilesMv(ctx, srcPath, dstPath); err != nil {
+		if hasBackup {
+			logger.LogIf(ctx, fs.shell.FilesMv(ctx, backupPath, dstPath))
+		}
+		return err
+	}
+	if hasBackup {
+		logger.LogIf(ctx, fs.shell.FilesRm(ctx, backupPath, true))
+	}
+	return nil
+}
+
+func (fs *IPFSObjects) commitObject(ctx context.Context, tmpPath, bucket, object string, meta ipfsMetaV1) error {
+	path := fs.path(bucket, object)
+	if isIPOSMetaBucketName(bucket) {
+		return fs.renameAll(ctx, tmpPath, path)
+	}
+
+	// Keep the current object aside until its new sidecar is in place, so
+	// that data and metadata never disagree after a failed write.
+	backupPath := fs.tmpPath(mustGetUUID())
+	err := fs.shell.FilesMv(ctx, path, backupPath)
+	if err != nil && !isIPFSErrNotFound(err) {
+		return err
+	}
+	hasBackup := err == nil
+
+	restore := func() {
+		if hasBackup {
+			logger.LogIf(ctx, fs.renameAll(ctx, backupPath, path))
+			return
+		}
+		fs.deleteEmptyParents(ctx, bucket, object)
+	}
+
+	if err = fs.renameAll(ctx, tmpPath, path); err != nil {
+		restore()
+		return err
+	}
+	if err = fs.writeMetadata(ctx, bucket, object, meta); err != nil {
+		if rerr := fs.shell.FilesRm(ctx, path, true); rerr != nil {
+			logger.LogIf(ctx, rerr)
+		}
+		restore()
+		return err
+	}
+
+	if hasBackup {
+		logger.LogIf(ctx, fs.shell.FilesRm(ctx, backupPath, true))
+	}
+	return nil
+}
+
 func (fs *IPFSObjects) initMetaVolumeFS() error {
 	metaBucketPath := fs.path(iposMetaBucket)
 	err := fs.shell.FilesMkdir(GlobalContext, metaBucketPath, shell.FilesMkdir.Parents(true))
 	if err != nil {
 		return fs.ipfsToObjectError(err, iposMetaBucket)
 	}
+
+	metaMultipartPath := fs.path(iposMetaMultipartBucket)
+	err = fs.shell.FilesMkdir(GlobalContext, metaMultipartPath, shell.FilesMkdir.Parents(true))
+	if err != nil {
+		return fs.ipfsToObjectError(err, iposMetaMultipartBucket)
+	}
+
+	metaTmpPath := fs.tmpPath("")
+	if err = fs.shell.FilesRm(GlobalContext, metaTmpPath, true); err != nil && !isIPFSErrNotFound(err) {
+		return fs.ipfsToObjectError(err, iposMetaTmpBucket)
+	}
+	err = fs.shell.FilesMkdir(GlobalContext, metaTmpPath, shell.FilesMkdir.Parents(true))
+	if err != nil {
+		return fs.ipfsToObjectError(err, iposMetaTmpBucket)
+	}
 	return nil
 }
 
 func (fs *IPFSObjects) NewNSLock(ctx context.Context, bucket string, objects ...string) RWLocker {
-	return nil
+	return fs.nsMutex.NewNSLock(ctx, fs.getLockers, bucket, objects...)
+}
+
+func (fs *IPFSObjects) getLockers() []dsync.NetLocker {
+	return globalLockers
 }
 
 func (fs *IPFSObjects) Shutdown(ctx context.Context) error {
+	err := fs.shell.FilesRm(ctx, fs.tmpPath(""), true)
+	if err != nil && !isIPFSErrNotFound(err) {
+		return fs.ipfsToObjectError(err, iposMetaTmpBucket)
+	}
 	return nil
 }
 
+type ipfsRepoStat struct {
+	RepoSize   uint64
+	StorageMax uint64
+	NumObjects uint64
+	RepoPath   string
+	Version    string
+}
+
 func (fs *IPFSObjects) StorageInfo(ctx context.Context, _ bool) StorageInfo {
 	storageInfo := StorageInfo{}
 	storageInfo.Backend.Type = BackendIPFS
+
+	var stat ipfsRepoStat
+	if err := fs.shell.Request("repo/stat").Exec(ctx, &stat); err != nil {
+		logger.LogIf(ctx, err)
+		return storageInfo
+	}
+
+	var available uint64
+	if stat.StorageMax > stat.RepoSize {
+		available = stat.StorageMax - stat.RepoSize
+	}
+
+	storageInfo.Used = []uint64{stat.RepoSize}
+	storageInfo.Total = []uint64{stat.StorageMax}
+	storageInfo.Available = []uint64{available}
+	storageInfo.MountPaths = []string{stat.RepoPath}
+	storageInfo.Backend.GatewayOnline = true
 	return storageInfo
 }
 
 func (fs *IPFSObjects) CrawlAndGetDataUsage(ctx context.Context, updates chan<- DataUsageInfo) error {
-	logger.LogIf(ctx, NotImplemented{})
-	return NotImplemented{}
+	defer close(updates)
+
+	buckets, err := fs.ListBuckets(ctx)
+	if err != nil {
+		return err
+	}
+
+	dataUsageInfo := DataUsageInfo{
+		BucketsCount:          uint64(len(buckets)),
+		BucketsSizes:          make(map[string]uint64, len(buckets)),
+		ObjectsSizesHistogram: make(map[string]uint64, dataUsageBucketLen),
+	}
+	for _, interval := range ObjectsHistogramIntervals {
+		dataUsageInfo.ObjectsSizesHistogram[interval.name] = 0
+	}
+
+	for _, bucket := range buckets {
+		size, err := fs.crawlDir(ctx, fs.path(bucket.Name), &dataUsageInfo)
+		if err != nil {
+			if isIPFSErrNotFound(err) {
+				continue
+			}
+			return fs.ipfsToObjectError(err, bucket.Name)
+		}
+		dataUsageInfo.BucketsSizes[bucket.Name] = size
+	}
+
+	dataUsageInfo.LastUpdate = UTCNow()
+
+	select {
+	case updates <- dataUsageInfo:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return nil
+}
+
+func (fs *IPFSObjects) crawlDir(ctx context.Context, dirPath string, dataUsageInfo *DataUsageInfo) (size uint64, err error) {
+	entries, err := fs.shell.FilesLs(ctx, dirPath, shell.FilesLs.Stat(true))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, entry := range entries {
+		if err = ctx.Err(); err != nil {
+			return size, err
+		}
+
+		if entry.Type == ipfsEntryTypeDirectory {
+			dirSize, err := fs.crawlDir(ctx, pathJoin(dirPath, entry.Name), dataUsageInfo)
+			if err != nil && !isIPFSErrNotFound(err) {
+				return size, err
+			}
+			size += dirSize
+			continue
+		}
+
+		size += entry.Size
+		dataUsageInfo.ObjectsCount++
+		dataUsageInfo.ObjectsTotalSize += entry.Size
+		for _, interval := range ObjectsHistogramIntervals {
+			if int64(entry.Size) >= interval.start && int64(entry.Size) <= interval.end {
+				dataUsageInfo.ObjectsSizesHistogram[interval.name]++
+				break
+			}
+		}
+	}
+
+	return size, nil
 }
 
 func (fs *IPFSObjects) MakeBucketWithLocation(ctx context.Context, bucket, location string) error {
@@ -178,48 +439,197 @@ func (fs *IPFSObjects) DeleteBucket(ctx context.Context, bucket string, forceDel
 		return fs.ipfsToObjectError(err, bucket)
 	}
 
+	fs.unpinPrefix(ctx, bucket, "")
+	if err = fs.deleteMetadata(ctx, bucket, ""); err != nil {
+		logger.LogIf(ctx, err)
+	}
+
 	return nil
 }
 
 func (fs *IPFSObjects) CopyObject(ctx context.Context, srcBucket, srcObject, dstBucket, dstObject string, srcInfo ObjectInfo, srcOpts, dstOpts ObjectOptions) (oi ObjectInfo, e error) {
-	logger.LogIf(ctx, NotImplemented{})
-	return ObjectInfo{}, NotImplemented{}
+	_, err := fs.shell.FilesStat(ctx, fs.path(dstBucket))
+	if err != nil {
+		return oi, fs.ipfsToObjectError(err, dstBucket)
+	}
+
+	objectDWLock := fs.NewNSLock(ctx, dstBucket, dstObject)
+	if err = objectDWLock.GetLock(globalObjectTimeout); err != nil {
+		return oi, err
+	}
+	defer objectDWLock.Unlock()
+	ctx = objectDWLock.Context()
+
+	if srcBucket != dstBucket || srcObject != dstObject {
+		objectSRLock := fs.NewNSLock(ctx, srcBucket, srcObject)
+		if err = objectSRLock.GetRLock(globalObjectTimeout); err != nil {
+			return oi, err
+		}
+		defer objectSRLock.RUnlock()
+	}
+
+	srcPath := fs.path(srcBucket, srcObject)
+	stat, err := fs.shell.FilesStat(ctx, srcPath)
+	if err != nil {
+		return oi, fs.ipfsToObjectError(err, srcBucket, srcObject)
+	}
+	if stat.Type == "directory" {
+		return oi, ObjectNotFound{Bucket: srcBucket, Object: srcObject}
+	}
+	// srcInfo was read before the source was locked, refuse to pair its
+	// metadata with content that has been replaced since.
+	if srcInfo.CID != "" && srcInfo.CID != stat.Hash {
+		return oi, PreConditionFailed{}
+	}
+
+	if srcOpts.CheckCopyPrecondFn != nil && srcOpts.CheckCopyPrecondFn(srcInfo, "") {
+		return oi, PreConditionFailed{}
+	}
+
+	if !srcInfo.metadataOnly && srcInfo.PutObjReader != nil {
+		return fs.putObject(ctx, dstBucket, dstObject, srcInfo.PutObjReader, ObjectOptions{UserDefined: srcInfo.UserDefined, CompressionIndexFn: dstOpts.CompressionIndexFn})
+	}
+
+	meta := newIPFSMetaV1()
+	meta.CID = stat.Hash
+	meta.ModTime = UTCNow()
+	meta.Meta = make(map[string]string, len(srcInfo.UserDefined)+1)
+	for k, v := range srcInfo.UserDefined {
+		meta.Meta[k] = v
+	}
+	meta.Meta["etag"] = srcInfo.ETag
+	meta.Parts = srcInfo.Parts
+	meta.CompressionIndex = srcInfo.CompressionIndex
+
+	if srcInfo.metadataOnly {
+		if srcInfo.Pin.CID != "" {
+			pin := srcInfo.Pin
+			meta.Pin = &pin
+		}
+		if err = fs.writeMetadata(ctx, dstBucket, dstObject, meta); err != nil {
+			return oi, fs.ipfsToObjectError(err, dstBucket, dstObject)
+		}
+		return meta.ToObjectInfo(dstBucket, dstObject, stat), nil
+	}
+
+	tmpPath := fs.tmpPath(mustGetUUID())
+	if err = fs.shell.FilesCp(ctx, "/ipfs/"+stat.Hash, tmpPath); err != nil {
+		return oi, fs.ipfsToObjectError(err, dstBucket, dstObject)
+	}
+	defer fs.shell.FilesRm(ctx, tmpPath, true)
+
+	oldPin := fs.readPinInfo(ctx, dstBucket, dstObject)
+	meta.Pin = fs.pinObject(ctx, dstBucket, dstObject, stat.Hash)
+
+	if err = fs.commitObject(ctx, tmpPath, dstBucket, dstObject, meta); err != nil {
+		fs.unpinObject(ctx, dstBucket, dstObject, meta.Pin, oldPin)
+		return oi, fs.ipfsToObjectError(err, dstBucket, dstObject)
+	}
+	fs.unpinObject(ctx, dstBucket, dstObject, oldPin, meta.Pin)
+
+	return meta.ToObjectInfo(dstBucket, dstObject, stat), nil
 }
 
 func (fs *IPFSObjects) GetObjectNInfo(ctx context.Context, bucket, object string, rs *HTTPRangeSpec, h http.Header, lockType LockType, opts ObjectOptions) (gr *GetObjectReader, err error) {
-	objInfo, err := fs.GetObjectInfo(ctx, bucket, object, opts)
-	if err != nil {
-		return nil, err
+	if _, err = fs.shell.FilesStat(ctx, fs.path(bucket)); err != nil {
+		return nil, fs.ipfsToObjectError(err, bucket)
+	}
+
+	var nsUnlocker = func() {}
+	var objInfo ObjectInfo
+	for adopted := false; ; adopted = true {
+		if lockType != noLock {
+			lock := fs.NewNSLock(ctx, bucket, object)
+			switch lockType {
+			case writeLock:
+				if err = lock.GetLock(globalObjectTimeout); err != nil {
+					logger.LogIf(ctx, err)
+					return nil, err
+				}
+				nsUnlocker = lock.Unlock
+			case readLock:
+				if err = lock.GetRLock(globalObjectTimeout); err != nil {
+					logger.LogIf(ctx, err)
+					return nil, err
+				}
+				nsUnlocker = lock.RUnlock
+			}
+		}
+
+		objInfo, err = fs.objectInfo(ctx, bucket, object, lockType == writeLock)
+		if err != nil {
+			nsUnlocker()
+			return nil, err
+		}
+		if adopted || !needsAdoption(objInfo) {
+			break
+		}
+
+		// Adopting writes the sidecar, which needs the write lock.
+		nsUnlocker()
+		nsUnlocker = func() {}
+		if _, err = fs.adoptObjectInfo(ctx, bucket, object, globalObjectTimeout); err != nil {
+			return nil, err
+		}
 	}
 
-	var startOffset, length int64
-	startOffset, length, err = rs.GetOffsetLength(objInfo.Size)
+	objReaderFn, off, length, err := NewGetObjectReader(rs, objInfo, opts, nsUnlocker)
 	if err != nil {
 		return nil, err
 	}
 
 	pr, pw := io.Pipe()
 	go func() {
-		nerr := fs.GetObject(ctx, bucket, object, startOffset, length, pw, objInfo.ETag, opts)
+		nerr := fs.getObject(ctx, bucket, object, off, length, pw)
 		pw.CloseWithError(nerr)
 	}()
 
 	pipeCloser := func() { pr.Close() }
-	return NewGetObjectReaderFromReader(pr, objInfo, opts, pipeCloser)
+	return objReaderFn(pr, h, opts.CheckCopyPrecondFn, pipeCloser)
 }
 
 func (fs *IPFSObjects) GetObject(ctx context.Context, bucket, object string, offset int64, length int64, writer io.Writer, etag string, opts ObjectOptions) error {
-	path := fs.path(bucket)
-	_, err := fs.shell.FilesStat(ctx, path)
+	_, err := fs.shell.FilesStat(ctx, fs.path(bucket))
 	if err != nil {
 		return fs.ipfsToObjectError(err, bucket)
 	}
 
-	path = fs.path(bucket, object)
-	reader, err := fs.shell.FilesRead(ctx, path)
+	objectLock := fs.NewNSLock(ctx, bucket, object)
+	if err = objectLock.GetRLock(globalObjectTimeout); err != nil {
+		logger.LogIf(ctx, err)
+		return err
+	}
+	defer objectLock.RUnlock()
+
+	return fs.getObject(ctx, bucket, object, offset, length, writer)
+}
+
+func (fs *IPFSObjects) getObject(ctx context.Context, bucket, object string, offset int64, length int64, writer io.Writer) error {
+	path := fs.path(bucket, object)
+	stat, err := fs.shell.FilesStat(ctx, path)
 	if err != nil {
 		return fs.ipfsToObjectError(err, bucket, object)
 	}
+
+	size := int64(stat.Size)
+	if length < 0 {
+		length = size - offset
+	}
+	if offset < 0 || offset > size || offset+length > size {
+		err = InvalidRange{offset, length, size}
+		logger.LogIf(ctx, err, logger.Application)
+		return err
+	}
+	if length == 0 {
+		return nil
+	}
+
+	reader, err := fs.shell.FilesRead(ctx, path, shell.FilesRead.Offset(offset), shell.FilesRead.Count(length))
+	if err != nil {
+		return fs.ipfsToObjectError(err, bucket, object)
+	}
+	defer reader.Close()
+
 	_, err = io.Copy(writer, reader)
 	if err != nil {
 		return fs.ipfsToObjectError(err, bucket, object)
@@ -229,49 +639,154 @@ func (fs *IPFSObjects) GetObject(ctx context.Context, bucket, object string, off
 }
 
 func (fs *IPFSObjects) GetObjectInfo(ctx context.Context, bucket, object string, opts ObjectOptions) (objInfo ObjectInfo, e error) {
-	path := fs.path(bucket, object)
-	stat, err := fs.shell.FilesStat(ctx, path)
+	path := fs.path(bucket)
+	_, err := fs.shell.FilesStat(ctx, path)
 	if err != nil {
-		return objInfo, fs.ipfsToObjectError(err, bucket, object)
+		return objInfo, fs.ipfsToObjectError(err, bucket)
 	}
-	return ObjectInfo{
-		Bucket:  bucket,
-		Name:    object,
-		ETag:    stat.Hash,
-		ModTime: time.Now(),
-		Size:    int64(stat.Size),
-		IsDir:   (stat.Type == "directory"),
-		AccTime: time.Now(),
-	}, nil
+
+	return fs.getObjectInfoWithLock(ctx, bucket, object)
 }
 
 func (fs *IPFSObjects) PutObject(ctx context.Context, bucket string, object string, r *PutObjReader, opts ObjectOptions) (objInfo ObjectInfo, retErr error) {
-	path := fs.path(bucket)
-	_, err := fs.shell.FilesStat(ctx, path)
+	_, err := fs.shell.FilesStat(ctx, fs.path(bucket))
 	if err != nil {
 		return objInfo, fs.ipfsToObjectError(err, bucket)
 	}
 
-	path = fs.path(bucket, object)
-	err = fs.shell.FilesWrite(ctx, path, r, shell.FilesWrite.Parents(true), shell.FilesWrite.Create(true))
+	objectLock := fs.NewNSLock(ctx, bucket, object)
+	if err = objectLock.GetLock(globalObjectTimeout); err != nil {
+		logger.LogIf(ctx, err)
+		return objInfo, err
+	}
+	defer objectLock.Unlock()
+	ctx = objectLock.Context()
+
+	return fs.putObject(ctx, bucket, object, r, opts)
+}
+
+func (fs *IPFSObjects) putObject(ctx context.Context, bucket string, object string, r *PutObjReader, opts ObjectOptions) (objInfo ObjectInfo, retErr error) {
+	path := fs.path(bucket, object)
+	if HasSuffix(object, SlashSeparator) {
+		err := fs.shell.FilesMkdir(ctx, path, shell.FilesMkdir.Parents(true))
+		if err != nil {
+			return objInfo, fs.ipfsToObjectError(err, bucket, object)
+		}
+		return fs.getObjectInfo(ctx, bucket, object)
+	}
+
+	if stat, err := fs.shell.FilesStat(ctx, path); err == nil && stat.Type == "directory" {
+		return objInfo, ObjectExistsAsDirectory{Bucket: bucket, Object: object}
+	}
+
+	data := r.Reader
+	tmpPath := fs.tmpPath(mustGetUUID())
+	err := fs.shell.FilesWrite(ctx, tmpPath, data, shell.FilesWrite.Create(true), shell.FilesWrite.Truncate(true))
 	if err != nil {
+		fs.shell.FilesRm(ctx, tmpPath, true)
+		if verr := data.Verify(); verr != nil {
+			return objInfo, verr
+		}
 		return objInfo, fs.ipfsToObjectError(err, bucket, object)
 	}
+	defer fs.shell.FilesRm(ctx, tmpPath, true)
 
-	stat, err := fs.shell.FilesStat(ctx, path)
+	stat, err := fs.shell.FilesStat(ctx, tmpPath)
 	if err != nil {
 		return objInfo, fs.ipfsToObjectError(err, bucket, object)
 	}
+	if data.Size() >= 0 && int64(stat.Size) < data.Size() {
+		return objInfo, IncompleteBody{Bucket: bucket, Object: object}
+	}
 
-	return ObjectInfo{
-		Bucket:  bucket,
-		Name:    object,
-		ETag:    stat.Hash,
-		ModTime: time.Now(),
-		Size:    int64(stat.Size),
-		IsDir:   (stat.Type == "directory"),
-		AccTime: time.Now(),
-	}, nil
+	meta := newIPFSMetaV1()
+	meta.CID = stat.Hash
+	meta.ModTime = UTCNow()
+	meta.Meta = make(map[string]string, len(opts.UserDefined)+1)
+	for k, v := range opts.UserDefined {
+		meta.Meta[k] = v
+	}
+	meta.Meta["etag"] = r.MD5CurrentHexString()
+	if opts.CompressionIndexFn != nil {
+		meta.CompressionIndex = opts.CompressionIndexFn()
+	}
+
+	oldPin := fs.readPinInfo(ctx, bucket, object)
+	meta.Pin = fs.pinObject(ctx, bucket, object, stat.Hash)
+
+	if err = fs.commitObject(ctx, tmpPath, bucket, object, meta); err != nil {
+		fs.unpinObject(ctx, bucket, object, meta.Pin, oldPin)
+		return objInfo, fs.ipfsToObjectError(err, bucket, object)
+	}
+	fs.unpinObject(ctx, bucket, object, oldPin, meta.Pin)
+
+	return meta.ToObjectInfo(bucket, object, stat), nil
+}
+
+func (fs *IPFSObjects) ImportObject(ctx context.Context, bucket, object, cid string, opts ObjectOptions) (objInfo ObjectInfo, err error) {
+	if err = checkObjectArgs(ctx, bucket, object, fs); err != nil {
+		return objInfo, err
+	}
+	if HasSuffix(object, SlashSeparator) {
+		return objInfo, ObjectNameInvalid{Bucket: bucket, Object: object}
+	}
+
+	tmpPath := fs.tmpPath(mustGetUUID())
+	if err = fs.shell.FilesCp(ctx, "/ipfs/"+cid, tmpPath); err != nil {
+		return objInfo, fs.ipfsToObjectError(err, bucket, object)
+	}
+	defer fs.shell.FilesRm(ctx, tmpPath, true)
+
+	stat, err := fs.shell.FilesStat(ctx, tmpPath)
+	if err != nil {
+		return objInfo, fs.ipfsToObjectError(err, bucket, object)
+	}
+	if stat.Type == "directory" {
+		return objInfo, InvalidCID{Bucket: bucket, Object: object, CID: cid}
+	}
+	if isMaxObjectSize(int64(stat.CumulativeSize)) {
+		return objInfo, ObjectTooLarge{Bucket: bucket, Object: object}
+	}
+
+	// Hashing reads the whole DAG, do it on the private copy before the
+	// object is locked.
+	etag, err := fs.md5Hash(ctx, tmpPath)
+	if err != nil {
+		return objInfo, fs.ipfsToObjectError(err, bucket, object)
+	}
+
+	objectLock := fs.NewNSLock(ctx, bucket, object)
+	if err = objectLock.GetLock(globalObjectTimeout); err != nil {
+		logger.LogIf(ctx, err)
+		return objInfo, err
+	}
+	defer objectLock.Unlock()
+	ctx = objectLock.Context()
+
+	path := fs.path(bucket, object)
+	if stat, err := fs.shell.FilesStat(ctx, path); err == nil && stat.Type == "directory" {
+		return objInfo, ObjectExistsAsDirectory{Bucket: bucket, Object: object}
+	}
+
+	meta := newIPFSMetaV1()
+	meta.CID = stat.Hash
+	meta.ModTime = UTCNow()
+	meta.Meta = make(map[string]string, len(opts.UserDefined)+1)
+	for k, v := range opts.UserDefined {
+		meta.Meta[k] = v
+	}
+	meta.Meta["etag"] = etag
+
+	oldPin := fs.readPinInfo(ctx, bucket, object)
+	meta.Pin = fs.pinObject(ctx, bucket, object, stat.Hash)
+
+	if err = fs.commitObject(ctx, tmpPath, bucket, object, meta); err != nil {
+		fs.unpinObject(ctx, bucket, object, meta.Pin, oldPin)
+		return objInfo, fs.ipfsToObjectError(err, bucket, object)
+	}
+	fs.unpinObject(ctx, bucket, object, oldPin, meta.Pin)
+
+	return meta.ToObjectInfo(bucket, object, stat), nil
 }
 
 func (fs *IPFSObjects) DeleteObjects(ctx context.Context, bucket string, objects []string) ([]error, error) {
@@ -297,7 +812,30 @@ func (fs *IPFSObjects) deleteObject(ctx context.Context, bucket, object string)
 		return nil
 	}
 
-	return fs.shell.FilesRm(ctx, path, true)
+	var pin *ObjectPinInfo
+	if stat.Type != "directory" {
+		pin = fs.readPinInfo(ctx, bucket, object)
+	}
+
+	if err = fs.shell.FilesRm(ctx, path, true); err != nil {
+		return err
+	}
+	fs.deleteEmptyParents(ctx, bucket, object)
+	fs.unpinObject(ctx, bucket, object, pin, nil)
+
+	return fs.deleteMetadata(ctx, bucket, object)
+}
+
+func (fs *IPFSObjects) deleteEmptyParents(ctx context.Context, bucket, object string) {
+	for dir := pathutil.Dir(object); dir != "." && dir != SlashSeparator; dir = pathutil.Dir(dir) {
+		list, err := fs.shell.FilesLs(ctx, fs.path(bucket, dir))
+		if err != nil || len(list) != 0 {
+			return
+		}
+		if err = fs.shell.FilesRm(ctx, fs.path(bucket, dir), true); err != nil {
+			return
+		}
+	}
 }
 
 func (fs *IPFSObjects) DeleteObject(ctx context.Context, bucket, object string) error {
@@ -307,7 +845,14 @@ func (fs *IPFSObjects) DeleteObject(ctx context.Context, bucket, object string)
 		return fs.ipfsToObjectError(err, bucket)
 	}
 
-	path = fs.path(bucket, object)
+	objectLock := fs.NewNSLock(ctx, bucket, object)
+	if err = objectLock.GetLock(globalObjectTimeout); err != nil {
+		logger.LogIf(ctx, err)
+		return err
+	}
+	defer objectLock.Unlock()
+	ctx = objectLock.Context()
+
 	err = fs.deleteObject(ctx, bucket, object)
 	if err != nil {
 		return fs.ipfsToObjectError(err, bucket, object)
@@ -331,17 +876,11 @@ func (fs *IPFSObjects) listDirFactory() ListDirFunc {
 			return true, nil
 		}
 		for _, entry := range list {
-			object := prefixDir + "/" + entry.Name
-			path := fs.path(bucket, object)
-			stat, err := fs.shell.FilesStat(GlobalContext, path)
-			if err == nil {
-				if stat.Type == "directory" {
-					entries = append(entries, entry.Name+SlashSeparator)
-				} else {
-					entries = append(entries, entry.Name)
-				}
+			if entry.Type == ipfsEntryTypeDirectory {
+				entries = append(entries, entry.Name+SlashSeparator)
+			} else {
+				entries = append(entries, entry.Name)
 			}
-
 		}
 		return false, filterMatchingPrefix(entries, prefixEntry)
 	}
@@ -349,22 +888,81 @@ func (fs *IPFSObjects) listDirFactory() ListDirFunc {
 	return listDir
 }
 
+func (fs *IPFSObjects) getObjectInfoWithLock(ctx context.Context, bucket, object string) (objInfo ObjectInfo, err error) {
+	objectLock := fs.NewNSLock(ctx, bucket, object)
+	if err = objectLock.GetRLock(globalObjectTimeout); err != nil {
+		return objInfo, err
+	}
+	objInfo, err = fs.objectInfo(ctx, bucket, object, false)
+	objectLock.RUnlock()
+
+	if err == nil && needsAdoption(objInfo) {
+		return fs.adoptObjectInfo(ctx, bucket, object, globalObjectTimeout)
+	}
+	return objInfo, err
+}
+
+func (fs *IPFSObjects) adoptObjectInfo(ctx context.Context, bucket, object string, timeout *dynamicTimeout) (objInfo ObjectInfo, err error) {
+	objectLock := fs.NewNSLock(ctx, bucket, object)
+	if err = objectLock.GetLock(timeout); err != nil {
+		return objInfo, err
+	}
+	defer objectLock.Unlock()
+
+	return fs.getObjectInfo(objectLock.Context(), bucket, object)
+}
+
+func needsAdoption(objInfo ObjectInfo) bool {
+	return !objInfo.IsDir && objInfo.ModTime.IsZero() && !isIPOSMetaBucketName(objInfo.Bucket)
+}
+
 func (fs *IPFSObjects) getObjectInfo(ctx context.Context, bucket, object string) (objInfo ObjectInfo, err error) {
+	return fs.objectInfo(ctx, bucket, object, true)
+}
+
+func (fs *IPFSObjects) listObjectInfo(ctx context.Context, bucket, object string) (objInfo ObjectInfo, err error) {
+	// Listings run without object locks, entries removed or replaced while
+	// walking are skipped. Objects written outside the gateway are adopted
+	// when their lock is free, otherwise they keep a zero modification time
+	// until a later listing or read adopts them.
+	objInfo, err = fs.objectInfo(ctx, bucket, object, false)
+	if err == nil && needsAdoption(objInfo) {
+		if adopted, aerr := fs.adoptObjectInfo(ctx, bucket, object, ipfsTryLockTimeout); aerr == nil {
+			objInfo = adopted
+		} else if _, ok := aerr.(ObjectNotFound); ok {
+			err = aerr
+		}
+	}
+	if _, ok := err.(ObjectNotFound); ok {
+		return objInfo, errFileNotFound
+	}
+	return objInfo, err
+}
+
+func (fs *IPFSObjects) objectInfo(ctx context.Context, bucket, object string, adopt bool) (objInfo ObjectInfo, err error) {
 	path := fs.path(bucket, object)
 	stat, err := fs.shell.FilesStat(ctx, path)
 	if err != nil {
 		return objInfo, fs.ipfsToObjectError(err, bucket, object)
 	}
 
-	return ObjectInfo{
-		Bucket:  bucket,
-		Name:    object,
-		ETag:    stat.Hash,
-		ModTime: time.Now(),
-		Size:    int64(stat.Size),
-		IsDir:   (stat.Type == "directory"),
-		AccTime: time.Now(),
-	}, nil
+	if stat.Type == "directory" {
+		return ObjectInfo{
+			Bucket:  bucket,
+			Name:    object,
+			ETag:    emptyETag,
+			ModTime: UTCNow(),
+			IsDir:   true,
+			AccTime: UTCNow(),
+		}, nil
+	}
+
+	meta, err := fs.readMetadata(ctx, bucket, object, stat, adopt)
+	if err != nil {
+		return objInfo, fs.ipfsToObjectError(err, bucket, object)
+	}
+
+	return meta.ToObjectInfo(bucket, object, stat), nil
 }
 
 func (fs *IPFSObjects) ListObjects(ctx context.Context, bucket, prefix, marker, delimiter string, maxKeys int) (loi ListObjectsInfo, e error) {
@@ -374,22 +972,61 @@ func (fs *IPFSObjects) ListObjects(ctx context.Context, bucket, prefix, marker,
 		return loi, fs.ipfsToObjectError(err, bucket)
 	}
 
-	return listObjects(ctx, fs, bucket, prefix, marker, delimiter, maxKeys, fs.listPool, fs.listDirFactory(), fs.getObjectInfo, fs.getObjectInfo)
+	return listObjects(ctx, fs, bucket, prefix, marker, delimiter, maxKeys, fs.listPool, fs.listDirFactory(), fs.listObjectInfo, fs.listObjectInfo)
 }
 
 func (fs *IPFSObjects) GetObjectTag(ctx context.Context, bucket, object string) (tagging.Tagging, error) {
-	logger.LogIf(ctx, NotImplemented{})
-	return tagging.Tagging{}, NotImplemented{}
+	oi, err := fs.GetObjectInfo(ctx, bucket, object, ObjectOptions{})
+	if err != nil {
+		return tagging.Tagging{}, err
+	}
+
+	return tagging.FromString(oi.UserTags)
 }
 
 func (fs *IPFSObjects) PutObjectTag(ctx context.Context, bucket, object string, tags string) error {
-	logger.LogIf(ctx, NotImplemented{})
-	return NotImplemented{}
+	path := fs.path(bucket)
+	_, err := fs.shell.FilesStat(ctx, path)
+	if err != nil {
+		return fs.ipfsToObjectError(err, bucket)
+	}
+
+	objectLock := fs.NewNSLock(ctx, bucket, object)
+	if err = objectLock.GetLock(globalObjectTimeout); err != nil {
+		logger.LogIf(ctx, err)
+		return err
+	}
+	defer objectLock.Unlock()
+	ctx = objectLock.Context()
+
+	stat, err := fs.shell.FilesStat(ctx, fs.path(bucket, object))
+	if err != nil {
+		return fs.ipfsToObjectError(err, bucket, object)
+	}
+	if stat.Type == "directory" {
+		return ObjectNotFound{Bucket: bucket, Object: object}
+	}
+
+	meta, err := fs.readMetadata(ctx, bucket, object, stat, true)
+	if err != nil {
+		return fs.ipfsToObjectError(err, bucket, object)
+	}
+
+	if tags == "" {
+		delete(meta.Meta, xhttp.AmzObjectTagging)
+	} else {
+		meta.Meta[xhttp.AmzObjectTagging] = tags
+	}
+
+	if err = fs.writeMetadata(ctx, bucket, object, meta); err != nil {
+		return fs.ipfsToObjectError(err, bucket, object)
+	}
+
+	return nil
 }
 
 func (fs *IPFSObjects) DeleteObjectTag(ctx context.Context, bucket, object string) error {
-	logger.LogIf(ctx, NotImplemented{})
-	return NotImplemented{}
+	return fs.PutObjectTag(ctx, bucket, object, "")
 }
 
 func (fs *IPFSObjects) ReloadFormat(ctx context.Context, dryRun bool) error {
@@ -414,8 +1051,35 @@ func (fs *IPFSObjects) HealBucket(ctx context.Context, bucket string, dryRun, re
 }
 
 func (fs *IPFSObjects) Walk(ctx context.Context, bucket, prefix string, results chan<- ObjectInfo) error {
-	logger.LogIf(ctx, NotImplemented{})
-	return NotImplemented{}
+	if _, err := fs.shell.FilesStat(ctx, fs.path(bucket)); err != nil {
+		return fs.ipfsToObjectError(err, bucket)
+	}
+
+	go func() {
+		defer close(results)
+
+		marker := ""
+		for {
+			loi, err := fs.ListObjects(ctx, bucket, prefix, marker, "", maxObjectList)
+			if err != nil {
+				logger.LogIf(ctx, err)
+				return
+			}
+			for _, objInfo := range loi.Objects {
+				select {
+				case results <- objInfo:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if !loi.IsTruncated {
+				return
+			}
+			marker = loi.NextMarker
+		}
+	}()
+
+	return nil
 }
 
 func (fs *IPFSObjects) ListBucketsHeal(ctx context.Context) ([]BucketInfo, error) {
@@ -424,8 +1088,7 @@ func (fs *IPFSObjects) ListBucketsHeal(ctx context.Context) ([]BucketInfo, error
 }
 
 func (fs *IPFSObjects) GetMetrics(ctx context.Context) (*Metrics, error) {
-	logger.LogIf(ctx, NotImplemented{})
-	return nil, NotImplemented{}
+	return fs.metrics, nil
 }
 
 func (fs *IPFSObjects) SetBucketPolicy(ctx context.Context, bucket string, policy *policy.Policy) error {
@@ -441,33 +1104,39 @@ func (fs *IPFSObjects) DeleteBucketPolicy(ctx context.Context, bucket string) er
 }
 
 func (fs *IPFSObjects) SetBucketLifecycle(ctx context.Context, bucket string, lifecycle *lifecycle.Lifecycle) error {
-	logger.LogIf(ctx, NotImplemented{})
-	return NotImplemented{}
+	return saveLifecycleConfig(ctx, fs, bucket, lifecycle)
 }
 
 func (fs *IPFSObjects) GetBucketLifecycle(ctx context.Context, bucket string) (*lifecycle.Lifecycle, error) {
-	logger.LogIf(ctx, NotImplemented{})
-	return nil, NotImplemented{}
+	return getLifecycleConfig(fs, bucket)
 }
 
 func (fs *IPFSObjects) DeleteBucketLifecycle(ctx context.Context, bucket string) error {
-	logger.LogIf(ctx, NotImplemented{})
-	return NotImplemented{}
+	return removeLifecycleConfig(ctx, fs, bucket)
 }
 
 func (fs *IPFSObjects) GetBucketSSEConfig(ctx context.Context, bucket string) (*bucketsse.BucketSSEConfig, error) {
-	logger.LogIf(ctx, NotImplemented{})
-	return nil, NotImplemented{}
+	return getBucketSSEConfig(fs, bucket)
 }
 
 func (fs *IPFSObjects) SetBucketSSEConfig(ctx context.Context, bucket string, config *bucketsse.BucketSSEConfig) error {
-	logger.LogIf(ctx, NotImplemented{})
-	return NotImplemented{}
+	return saveBucketSSEConfig(ctx, fs, bucket, config)
 }
 
 func (fs *IPFSObjects) DeleteBucketSSEConfig(ctx context.Context, bucket string) error {
-	logger.LogIf(ctx, NotImplemented{})
-	return NotImplemented{}
+	return removeBucketSSEConfig(ctx, fs, bucket)
+}
+
+func (fs *IPFSObjects) GetBucketPinning(ctx context.Context, bucket string) (*pinning.Config, error) {
+	return getBucketPinningConfig(fs, bucket)
+}
+
+func (fs *IPFSObjects) SetBucketPinning(ctx context.Context, bucket string, config *pinning.Config) error {
+	return saveBucketPinningConfig(ctx, fs, bucket, config)
+}
+
+func (fs *IPFSObjects) DeleteBucketPinning(ctx context.Context, bucket string) error {
+	return removeBucketPinningConfig(ctx, fs, bucket)
 }
 
 func (fs *IPFSObjects) ListObjectsV2(ctx context.Context, bucket, prefix, continuationToken, delimiter string, maxKeys int, fetchOwner bool, startAfter string) (loi ListObjectsV2Info, err error) {
@@ -497,7 +1166,7 @@ func (fs *IPFSObjects) IsListenBucketSupported() bool {
 }
 
 func (fs *IPFSObjects) IsEncryptionSupported() bool {
-	return false
+	return true
 }
 
 func (fs *IPFSObjects) IsCompressionSupported() bool {
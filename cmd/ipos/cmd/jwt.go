@@ -8,6 +8,7 @@ import (
 	jwtgo "github.com/dgrijalva/jwt-go"
 	jwtreq "github.com/dgrijalva/jwt-go/request"
 
+	xhttp "github.com/storeros/ipos/cmd/ipos/http"
 	xjwt "github.com/storeros/ipos/cmd/ipos/jwt"
 	"github.com/storeros/ipos/cmd/ipos/logger"
 	"github.com/storeros/ipos/pkg/auth"
@@ -29,6 +30,7 @@ var (
 	errAuthentication       = errors.New("Authentication failed, check your access credentials")
 	errNoAuthToken          = errors.New("JWT token missing")
 	errIncorrectCreds       = errors.New("Current access key or secret key is incorrect")
+	errSkewedAuthTime       = errors.New("Inter-node request time too far from server time")
 )
 
 func authenticateJWTUsers(accessKey, secretKey string, expiry time.Duration) (string, error) {
@@ -138,6 +140,45 @@ func webRequestAuthenticate(req *http.Request) (*xjwt.MapClaims, bool, error) {
 	return claims, owner, nil
 }
 
+func nodeRequestAuthenticate(req *http.Request) error {
+	token, err := jwtreq.AuthorizationHeaderExtractor.ExtractToken(req)
+	if err != nil {
+		if err == jwtreq.ErrNoTokenInRequest {
+			return errNoAuthToken
+		}
+		return err
+	}
+
+	claims := xjwt.NewStandardClaims()
+	if err = xjwt.ParseWithStandardClaims(token, claims, []byte(globalActiveCred.SecretKey)); err != nil {
+		return errAuthentication
+	}
+
+	owner := claims.AccessKey == globalActiveCred.AccessKey || claims.Subject == globalActiveCred.AccessKey
+	if !owner {
+		return errAuthentication
+	}
+
+	if claims.Audience != req.URL.Query().Encode() {
+		return errAuthentication
+	}
+
+	requestTime, err := time.Parse(time.RFC3339, req.Header.Get(xhttp.IPOSTime))
+	if err != nil {
+		return err
+	}
+
+	skew := UTCNow().Sub(requestTime)
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > globalMaxSkewTime {
+		return errSkewedAuthTime
+	}
+
+	return nil
+}
+
 func newAuthToken(audience string) string {
 	cred := globalActiveCred
 	token, err := authenticateNode(cred.AccessKey, cred.SecretKey, audience)
@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/storeros/ipos/pkg/dsync"
+)
+
+type lockRequesterInfo struct {
+	Writer          bool
+	UID             string
+	Timestamp       time.Time
+	TimeLastRefresh time.Time
+	Source          string
+}
+
+func isWriteLock(lri []lockRequesterInfo) bool {
+	return len(lri) == 1 && lri[0].Writer
+}
+
+type localLocker struct {
+	mutex    sync.Mutex
+	endpoint Endpoint
+	lockMap  map[string][]lockRequesterInfo
+}
+
+func (l *localLocker) String() string {
+	return l.endpoint.String()
+}
+
+func (l *localLocker) canTakeLock(resources ...string) bool {
+	for _, resource := range resources {
+		if _, lockTaken := l.lockMap[resource]; lockTaken {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *localLocker) canTakeRLock(resources ...string) bool {
+	for _, resource := range resources {
+		if isWriteLock(l.lockMap[resource]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *localLocker) Lock(args dsync.LockArgs) (reply bool, err error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if !l.canTakeLock(args.Resources...) {
+		return false, nil
+	}
+
+	now := UTCNow()
+	for _, resource := range args.Resources {
+		l.lockMap[resource] = []lockRequesterInfo{
+			{
+				Writer:          true,
+				UID:             args.UID,
+				Timestamp:       now,
+				TimeLastRefresh: now,
+				Source:          args.Source,
+			},
+		}
+	}
+	return true, nil
+}
+
+func (l *localLocker) Unlock(args dsync.LockArgs) (reply bool, err error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for _, resource := range args.Resources {
+		lri, ok := l.lockMap[resource]
+		if !ok {
+			continue
+		}
+		if !isWriteLock(lri) {
+			return false, fmt.Errorf("Unlock attempted on a read locked entity: %s", resource)
+		}
+	}
+
+	for _, resource := range args.Resources {
+		l.removeEntry(resource, args.UID)
+	}
+	return true, nil
+}
+
+func (l *localLocker) RLock(args dsync.LockArgs) (reply bool, err error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if !l.canTakeRLock(args.Resources...) {
+		return false, nil
+	}
+
+	now := UTCNow()
+	for _, resource := range args.Resources {
+		l.lockMap[resource] = append(l.lockMap[resource], lockRequesterInfo{
+			Writer:          false,
+			UID:             args.UID,
+			Timestamp:       now,
+			TimeLastRefresh: now,
+			Source:          args.Source,
+		})
+	}
+	return true, nil
+}
+
+func (l *localLocker) RUnlock(args dsync.LockArgs) (reply bool, err error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for _, resource := range args.Resources {
+		if isWriteLock(l.lockMap[resource]) {
+			return false, fmt.Errorf("RUnlock attempted on a write locked entity: %s", resource)
+		}
+	}
+
+	for _, resource := range args.Resources {
+		l.removeEntry(resource, args.UID)
+	}
+	return true, nil
+}
+
+func (l *localLocker) Refresh(args dsync.LockArgs) (refreshed bool, err error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := UTCNow()
+	for _, resource := range args.Resources {
+		lri := l.lockMap[resource]
+		for i := range lri {
+			if lri[i].UID == args.UID {
+				lri[i].TimeLastRefresh = now
+				refreshed = true
+			}
+		}
+	}
+	return refreshed, nil
+}
+
+func (l *localLocker) Expired(args dsync.LockArgs) (expired bool, err error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for _, resource := range args.Resources {
+		for _, entry := range l.lockMap[resource] {
+			if entry.UID == args.UID {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+func (l *localLocker) expireOldLocks(interval time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for resource, lri := range l.lockMap {
+		for _, entry := range lri {
+			if UTCNow().Sub(entry.TimeLastRefresh) > interval {
+				l.removeEntry(resource, entry.UID)
+			}
+		}
+	}
+}
+
+func (l *localLocker) removeEntry(resource, uid string) bool {
+	lri := l.lockMap[resource]
+	for index, entry := range lri {
+		if entry.UID != uid {
+			continue
+		}
+		if len(lri) == 1 {
+			delete(l.lockMap, resource)
+		} else {
+			l.lockMap[resource] = append(lri[:index:index], lri[index+1:]...)
+		}
+		return true
+	}
+	return false
+}
+
+func (l *localLocker) Close() error {
+	return nil
+}
+
+func (l *localLocker) IsOnline() bool {
+	return true
+}
+
+func newLocker(endpoint Endpoint) *localLocker {
+	return &localLocker{
+		endpoint: endpoint,
+		lockMap:  make(map[string][]lockRequesterInfo),
+	}
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net/url"
+
+	xhttp "github.com/storeros/ipos/cmd/ipos/http"
+	"github.com/storeros/ipos/cmd/ipos/rest"
+	"github.com/storeros/ipos/pkg/dsync"
+)
+
+type lockRESTClient struct {
+	restClient *rest.Client
+	endpoint   Endpoint
+}
+
+func toLockError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	switch err.Error() {
+	case errLockConflict.Error():
+		return errLockConflict
+	case errLockNotExpired.Error():
+		return errLockNotExpired
+	case errLockNotFound.Error():
+		return errLockNotFound
+	}
+	return err
+}
+
+func (client *lockRESTClient) String() string {
+	return client.endpoint.String()
+}
+
+func (client *lockRESTClient) call(method string, values url.Values, body io.Reader, length int64) (respBody io.ReadCloser, err error) {
+	if values == nil {
+		values = make(url.Values)
+	}
+
+	respBody, err = client.restClient.Call(method, values, body, length)
+	if err == nil {
+		return respBody, nil
+	}
+
+	return nil, toLockError(err)
+}
+
+func (client *lockRESTClient) IsOnline() bool {
+	return client.restClient.IsOnline()
+}
+
+func (client *lockRESTClient) Close() error {
+	client.restClient.Close()
+	return nil
+}
+
+func (client *lockRESTClient) restCall(call string, args dsync.LockArgs) (reply bool, err error) {
+	values := url.Values{}
+	values.Set(lockRESTUID, args.UID)
+	values.Set(lockRESTSource, args.Source)
+
+	var buffer bytes.Buffer
+	for _, resource := range args.Resources {
+		buffer.WriteString(resource)
+		buffer.WriteString("\n")
+	}
+
+	respBody, err := client.call(call, values, &buffer, int64(buffer.Len()))
+	defer xhttp.DrainBody(respBody)
+	switch err {
+	case nil:
+		return true, nil
+	case errLockConflict, errLockNotExpired, errLockNotFound:
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+func (client *lockRESTClient) RLock(args dsync.LockArgs) (reply bool, err error) {
+	return client.restCall(lockRESTMethodRLock, args)
+}
+
+func (client *lockRESTClient) Lock(args dsync.LockArgs) (reply bool, err error) {
+	return client.restCall(lockRESTMethodLock, args)
+}
+
+func (client *lockRESTClient) RUnlock(args dsync.LockArgs) (reply bool, err error) {
+	return client.restCall(lockRESTMethodRUnlock, args)
+}
+
+func (client *lockRESTClient) Unlock(args dsync.LockArgs) (reply bool, err error) {
+	return client.restCall(lockRESTMethodUnlock, args)
+}
+
+func (client *lockRESTClient) Refresh(args dsync.LockArgs) (reply bool, err error) {
+	return client.restCall(lockRESTMethodRefresh, args)
+}
+
+func (client *lockRESTClient) Expired(args dsync.LockArgs) (expired bool, err error) {
+	return client.restCall(lockRESTMethodExpired, args)
+}
+
+func newLockAPI(endpoint Endpoint) dsync.NetLocker {
+	if endpoint.IsLocal {
+		return globalLockServer
+	}
+	return newlockRESTClient(endpoint)
+}
+
+func newlockRESTClient(endpoint Endpoint) *lockRESTClient {
+	serverURL := &url.URL{
+		Scheme: endpoint.Scheme,
+		Host:   endpoint.Host,
+		Path:   pathJoin(lockRESTPrefix, lockRESTVersion),
+	}
+
+	var tlsConfig *tls.Config
+	if endpoint.HTTPS() {
+		tlsConfig = &tls.Config{
+			ServerName: endpoint.Hostname(),
+			RootCAs:    globalRootCAs,
+		}
+	}
+
+	trFn := newCustomHTTPTransport(tlsConfig, defaultDialTimeout)
+	restClient := rest.NewClient(serverURL, trFn, newAuthToken)
+	restClient.HealthCheckFn = func() bool {
+		ctx, cancel := context.WithTimeout(GlobalContext, restClient.HealthCheckTimeout)
+		respBody, err := restClient.CallWithContext(ctx, lockRESTMethodHealth, nil, nil, -1)
+		xhttp.DrainBody(respBody)
+		cancel()
+		var ne *rest.NetworkError
+		return !errors.As(err, &ne)
+	}
+
+	return &lockRESTClient{endpoint: endpoint, restClient: restClient}
+}
+
+func newLockAPIs(endpoints Endpoints) []dsync.NetLocker {
+	lockers := make([]dsync.NetLocker, len(endpoints))
+	for i, endpoint := range endpoints {
+		lockers[i] = newLockAPI(endpoint)
+	}
+	return lockers
+}
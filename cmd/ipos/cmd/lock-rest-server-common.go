@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	lockRESTVersion       = "v1"
+	lockRESTVersionPrefix = SlashSeparator + lockRESTVersion
+	lockRESTPrefix        = iposReservedBucketPath + "/lock"
+)
+
+const (
+	lockRESTMethodHealth  = "/health"
+	lockRESTMethodLock    = "/lock"
+	lockRESTMethodRLock   = "/rlock"
+	lockRESTMethodUnlock  = "/unlock"
+	lockRESTMethodRUnlock = "/runlock"
+	lockRESTMethodRefresh = "/refresh"
+	lockRESTMethodExpired = "/expired"
+
+	lockRESTUID    = "uid"
+	lockRESTSource = "source"
+)
+
+const (
+	lockMaintenanceInterval = 30 * time.Second
+
+	lockValidityDuration = 1 * time.Minute
+)
+
+var (
+	errLockConflict   = errors.New("lock conflict")
+	errLockNotExpired = errors.New("lock not expired")
+	errLockNotFound   = errors.New("lock not found")
+)
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/storeros/ipos/pkg/dsync"
+)
+
+type lockRESTServer struct {
+	ll *localLocker
+}
+
+func (l *lockRESTServer) writeErrorResponse(w http.ResponseWriter, err error) {
+	w.WriteHeader(http.StatusForbidden)
+	w.Write([]byte(err.Error()))
+}
+
+func (l *lockRESTServer) IsValid(w http.ResponseWriter, r *http.Request) bool {
+	if err := nodeRequestAuthenticate(r); err != nil {
+		l.writeErrorResponse(w, err)
+		return false
+	}
+	return true
+}
+
+func getLockArgs(r *http.Request) (args dsync.LockArgs, err error) {
+	args = dsync.LockArgs{
+		UID:    r.URL.Query().Get(lockRESTUID),
+		Source: r.URL.Query().Get(lockRESTSource),
+	}
+
+	var resources []string
+	bio := bufio.NewScanner(r.Body)
+	for bio.Scan() {
+		resources = append(resources, bio.Text())
+	}
+	if err = bio.Err(); err != nil {
+		return args, err
+	}
+
+	if args.UID == "" || len(resources) == 0 {
+		return args, errors.New("lock uid and resources are required")
+	}
+
+	sort.Strings(resources)
+	args.Resources = resources
+	return args, nil
+}
+
+func (l *lockRESTServer) HealthHandler(w http.ResponseWriter, r *http.Request) {
+	l.IsValid(w, r)
+}
+
+func (l *lockRESTServer) handle(w http.ResponseWriter, r *http.Request, fn func(dsync.LockArgs) (bool, error), failErr error) {
+	if !l.IsValid(w, r) {
+		return
+	}
+
+	args, err := getLockArgs(r)
+	if err != nil {
+		l.writeErrorResponse(w, err)
+		return
+	}
+
+	ok, err := fn(args)
+	if err == nil && !ok {
+		err = failErr
+	}
+	if err != nil {
+		l.writeErrorResponse(w, err)
+		return
+	}
+}
+
+func (l *lockRESTServer) LockHandler(w http.ResponseWriter, r *http.Request) {
+	l.handle(w, r, l.ll.Lock, errLockConflict)
+}
+
+func (l *lockRESTServer) UnlockHandler(w http.ResponseWriter, r *http.Request) {
+	l.handle(w, r, l.ll.Unlock, errLockNotFound)
+}
+
+func (l *lockRESTServer) RLockHandler(w http.ResponseWriter, r *http.Request) {
+	l.handle(w, r, l.ll.RLock, errLockConflict)
+}
+
+func (l *lockRESTServer) RUnlockHandler(w http.ResponseWriter, r *http.Request) {
+	l.handle(w, r, l.ll.RUnlock, errLockNotFound)
+}
+
+func (l *lockRESTServer) RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	l.handle(w, r, l.ll.Refresh, errLockNotFound)
+}
+
+func (l *lockRESTServer) ExpiredHandler(w http.ResponseWriter, r *http.Request) {
+	l.handle(w, r, l.ll.Expired, errLockNotExpired)
+}
+
+func lockMaintenance(ctx context.Context, ll *localLocker) {
+	ticker := time.NewTicker(lockMaintenanceInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ll.expireOldLocks(lockValidityDuration)
+		}
+	}
+}
+
+func registerLockRESTHandlers(router *mux.Router, endpoints Endpoints) {
+	for _, endpoint := range endpoints {
+		if !endpoint.IsLocal {
+			continue
+		}
+
+		lockServer := &lockRESTServer{
+			ll: newLocker(endpoint),
+		}
+
+		subrouter := router.PathPrefix(lockRESTPrefix).Subrouter()
+		subrouter.Methods(http.MethodPost).Path(lockRESTVersionPrefix + lockRESTMethodHealth).HandlerFunc(httpTraceHdrs(lockServer.HealthHandler))
+		subrouter.Methods(http.MethodPost).Path(lockRESTVersionPrefix + lockRESTMethodLock).HandlerFunc(httpTraceHdrs(lockServer.LockHandler))
+		subrouter.Methods(http.MethodPost).Path(lockRESTVersionPrefix + lockRESTMethodRLock).HandlerFunc(httpTraceHdrs(lockServer.RLockHandler))
+		subrouter.Methods(http.MethodPost).Path(lockRESTVersionPrefix + lockRESTMethodUnlock).HandlerFunc(httpTraceHdrs(lockServer.UnlockHandler))
+		subrouter.Methods(http.MethodPost).Path(lockRESTVersionPrefix + lockRESTMethodRUnlock).HandlerFunc(httpTraceHdrs(lockServer.RUnlockHandler))
+		subrouter.Methods(http.MethodPost).Path(lockRESTVersionPrefix + lockRESTMethodRefresh).HandlerFunc(httpTraceHdrs(lockServer.RefreshHandler))
+		subrouter.Methods(http.MethodPost).Path(lockRESTVersionPrefix + lockRESTMethodExpired).HandlerFunc(httpTraceHdrs(lockServer.ExpiredHandler))
+
+		globalLockServer = lockServer.ll
+
+		go lockMaintenance(GlobalContext, globalLockServer)
+		return
+	}
+}
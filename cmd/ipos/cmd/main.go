@@ -12,7 +12,14 @@ import (
 	"github.com/storeros/ipos/version"
 )
 
-var GlobalFlags = []cli.Flag{}
+var GlobalFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:   "certs-dir, S",
+		Value:  defaultCertsDir.Get(),
+		EnvVar: "IPOS_CERTS_DIR",
+		Usage:  "path to certs directory",
+	},
+}
 
 var iposHelpTemplate = `NAME:
   {{.Name}} - {{.Usage}}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"github.com/gorilla/mux"
+)
+
+const (
+	prometheusMetricsPath = "/prometheus/metrics"
+)
+
+type prometheusAuthType string
+
+const (
+	prometheusJWT    prometheusAuthType = "jwt"
+	prometheusPublic prometheusAuthType = "public"
+)
+
+func registerMetricsRouter(router *mux.Router) {
+	metricsRouter := router.NewRoute().PathPrefix(iposReservedBucketPath).Subrouter()
+
+	switch globalPrometheusAuthType {
+	case prometheusPublic:
+		metricsRouter.Handle(prometheusMetricsPath, metricsHandler())
+	default:
+		metricsRouter.Handle(prometheusMetricsPath, AuthMiddleware(metricsHandler()))
+	}
+}
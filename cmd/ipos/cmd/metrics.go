@@ -1,11 +1,22 @@
 package cmd
 
 import (
+	"bytes"
+	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 
 	"go.uber.org/atomic"
+
+	xhttp "github.com/storeros/ipos/cmd/ipos/http"
+	"github.com/storeros/ipos/cmd/ipos/logger"
 )
 
+var latencyBuckets = []float64{.05, .1, .25, .5, 1, 2.5, 5, 10}
+
 type RequestStats struct {
 	Get  atomic.Uint64 `json:"Get"`
 	Head atomic.Uint64 `json:"Head"`
@@ -17,6 +28,10 @@ type Metrics struct {
 	bytesReceived atomic.Uint64
 	bytesSent     atomic.Uint64
 	requestStats  RequestStats
+
+	totalCalls  HTTPAPIStats
+	totalErrors HTTPAPIStats
+	callLatency *histogramVec
 }
 
 func (s *Metrics) IncBytesReceived(n uint64) {
@@ -51,6 +66,177 @@ func (s *Metrics) GetRequests() RequestStats {
 	return s.requestStats
 }
 
+func (s *Metrics) ObserveCall(call string, durationSecs float64, failed bool) {
+	s.totalCalls.Inc(call)
+	if failed {
+		s.totalErrors.Inc(call)
+	}
+	s.callLatency.Observe(call, durationSecs)
+}
+
 func NewMetrics() *Metrics {
-	return &Metrics{}
+	return &Metrics{
+		callLatency: newHistogramVec(latencyBuckets),
+	}
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+type histogramVec struct {
+	sync.Mutex
+	buckets []float64
+	values  map[string]*histogram
+}
+
+func (h *histogramVec) Observe(label string, v float64) {
+	h.Lock()
+	defer h.Unlock()
+
+	hist, ok := h.values[label]
+	if !ok {
+		hist = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.values[label] = hist
+	}
+	for i, le := range h.buckets {
+		if v <= le {
+			hist.counts[i]++
+		}
+	}
+	hist.count++
+	hist.sum += v
+}
+
+func newHistogramVec(buckets []float64) *histogramVec {
+	return &histogramVec{
+		buckets: buckets,
+		values:  make(map[string]*histogram),
+	}
+}
+
+type metricsWriter struct {
+	bytes.Buffer
+}
+
+func escapeLabelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+func (m *metricsWriter) header(name, help, metricType string) {
+	fmt.Fprintf(m, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+func (m *metricsWriter) value(name, labels string, v float64) {
+	if labels != "" {
+		labels = "{" + labels + "}"
+	}
+	fmt.Fprintf(m, "%s%s %s\n", name, labels, strconv.FormatFloat(v, 'g', -1, 64))
+}
+
+func (m *metricsWriter) counter(name, help string, v float64) {
+	m.header(name, help, "counter")
+	m.value(name, "", v)
+}
+
+func (m *metricsWriter) apiStats(name, help, metricType, labelName string, stats map[string]int) {
+	m.header(name, help, metricType)
+	keys := make([]string, 0, len(stats))
+	for k := range stats {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		m.value(name, labelName+`="`+escapeLabelValue(k)+`"`, float64(stats[k]))
+	}
+}
+
+func (m *metricsWriter) histogram(name, help, labelName string, h *histogramVec) {
+	m.header(name, help, "histogram")
+
+	h.Lock()
+	defer h.Unlock()
+
+	keys := make([]string, 0, len(h.values))
+	for k := range h.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		hist := h.values[k]
+		label := labelName + `="` + escapeLabelValue(k) + `"`
+		for i, le := range h.buckets {
+			m.value(name+"_bucket", label+`,le="`+strconv.FormatFloat(le, 'g', -1, 64)+`"`, float64(hist.counts[i]))
+		}
+		m.value(name+"_bucket", label+`,le="+Inf"`, float64(hist.count))
+		m.value(name+"_sum", label, hist.sum)
+		m.value(name+"_count", label, float64(hist.count))
+	}
+}
+
+func (m *metricsWriter) httpMetrics() {
+	m.apiStats("ipos_s3_requests_total", "Total number of S3 requests", "counter", "api", globalHTTPStats.totalS3Requests.Load())
+	m.apiStats("ipos_s3_errors_total", "Total number of S3 requests with errors", "counter", "api", globalHTTPStats.totalS3Errors.Load())
+	m.apiStats("ipos_s3_requests_current", "Total number of S3 requests in flight", "gauge", "api", globalHTTPStats.currentS3Requests.Load())
+	m.histogram("ipos_s3_ttfb_seconds", "Time taken by S3 requests to write the first byte of the response", "api", globalHTTPStats.s3RequestsTTFB)
+	m.apiStats("ipos_s3_throttled_total", "Total number of S3 requests rejected or dropped by throttling", "counter", "reason", globalAPIThrottling.throttled.Load())
+}
+
+func (m *metricsWriter) networkMetrics() {
+	m.counter("ipos_s3_rx_bytes_total", "Total number of S3 bytes received", float64(globalConnStats.getS3InputBytes()))
+	m.counter("ipos_s3_tx_bytes_total", "Total number of S3 bytes sent", float64(globalConnStats.getS3OutputBytes()))
+	m.counter("ipos_network_received_bytes_total", "Total number of bytes received", float64(globalConnStats.getTotalInputBytes()))
+	m.counter("ipos_network_sent_bytes_total", "Total number of bytes sent", float64(globalConnStats.getTotalOutputBytes()))
+}
+
+func (m *metricsWriter) ipfsMetrics(metrics *Metrics) {
+	m.counter("ipos_ipfs_received_bytes_total", "Total number of bytes received from the IPFS API", float64(metrics.GetBytesReceived()))
+	m.counter("ipos_ipfs_sent_bytes_total", "Total number of bytes sent to the IPFS API", float64(metrics.GetBytesSent()))
+
+	requests := metrics.GetRequests()
+	m.header("ipos_ipfs_requests_by_method_total", "Total number of IPFS API requests by HTTP method", "counter")
+	m.value("ipos_ipfs_requests_by_method_total", `method="GET"`, float64(requests.Get.Load()))
+	m.value("ipos_ipfs_requests_by_method_total", `method="HEAD"`, float64(requests.Head.Load()))
+	m.value("ipos_ipfs_requests_by_method_total", `method="PUT"`, float64(requests.Put.Load()))
+	m.value("ipos_ipfs_requests_by_method_total", `method="POST"`, float64(requests.Post.Load()))
+
+	m.apiStats("ipos_ipfs_requests_total", "Total number of IPFS API calls", "counter", "command", metrics.totalCalls.Load())
+	m.apiStats("ipos_ipfs_errors_total", "Total number of failed IPFS API calls", "counter", "command", metrics.totalErrors.Load())
+	m.histogram("ipos_ipfs_request_duration_seconds", "Time taken by IPFS API calls to return response headers", "command", metrics.callLatency)
+}
+
+func metricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := newContext(r, w, "Metrics")
+
+		var m metricsWriter
+		m.httpMetrics()
+		m.networkMetrics()
+
+		if objLayer := newObjectLayerFn(); objLayer != nil {
+			metrics, err := objLayer.GetMetrics(ctx)
+			if err != nil {
+				logger.LogIf(ctx, err)
+			} else {
+				m.ipfsMetrics(metrics)
+			}
+		}
+
+		w.Header().Set(xhttp.ContentType, "text/plain; version=0.0.4")
+		w.WriteHeader(http.StatusOK)
+		w.Write(m.Bytes())
+	})
+}
+
+func AuthMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, _, authErr := webRequestAuthenticate(r)
+		if authErr != nil || !claims.VerifyIssuer("prometheus", true) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
 }
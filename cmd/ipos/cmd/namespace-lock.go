@@ -22,12 +22,18 @@ type RWLocker interface {
 	Unlock()
 	GetRLock(timeout *dynamicTimeout) (timedOutErr error)
 	RUnlock()
+	Context() context.Context
 }
 
 func newNSLock(isDistXL bool) *nsLockMap {
-	return &nsLockMap{
-		lockMap: make(map[string]*nsLock),
+	nsMutex := nsLockMap{
+		isDistXL: isDistXL,
 	}
+	if isDistXL {
+		return &nsMutex
+	}
+	nsMutex.lockMap = make(map[string]*nsLock)
+	return &nsMutex
 }
 
 type nsLock struct {
@@ -36,6 +42,8 @@ type nsLock struct {
 }
 
 type nsLockMap struct {
+	isDistXL bool
+
 	lockMap      map[string]*nsLock
 	lockMapMutex sync.RWMutex
 }
@@ -137,6 +145,10 @@ func (di *distLockInstance) RUnlock() {
 	di.rwMutex.RUnlock()
 }
 
+func (di *distLockInstance) Context() context.Context {
+	return di.rwMutex.Context()
+}
+
 type localLockInstance struct {
 	ctx    context.Context
 	ns     *nsLockMap
@@ -147,6 +159,12 @@ type localLockInstance struct {
 
 func (n *nsLockMap) NewNSLock(ctx context.Context, lockersFn func() []dsync.NetLocker, volume string, paths ...string) RWLocker {
 	opsID := mustGetUUID()
+	if n.isDistXL {
+		drwmutex := dsync.NewDRWMutex(ctx, &dsync.Dsync{
+			GetLockersFn: lockersFn,
+		}, pathsJoinPrefix(volume, paths...)...)
+		return &distLockInstance{drwmutex, opsID}
+	}
 	sort.Strings(paths)
 	return &localLockInstance{ctx, n, volume, paths, opsID}
 }
@@ -203,6 +221,10 @@ func (li *localLockInstance) RUnlock() {
 	}
 }
 
+func (li *localLockInstance) Context() context.Context {
+	return li.ctx
+}
+
 func getSource() string {
 	var funcName string
 	pc, filename, lineNum, ok := runtime.Caller(2)
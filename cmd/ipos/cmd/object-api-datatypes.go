@@ -78,6 +78,12 @@ type BucketInfo struct {
 	Created time.Time
 }
 
+type ObjectPartInfo struct {
+	Number     int   `json:"number"`
+	Size       int64 `json:"size"`
+	ActualSize int64 `json:"actualSize"`
+}
+
 type ObjectInfo struct {
 	Bucket string
 
@@ -103,6 +109,14 @@ type ObjectInfo struct {
 
 	UserTags string
 
+	CID string
+
+	Parts []ObjectPartInfo `json:"-"`
+
+	CompressionIndex []byte `json:"-"`
+
+	Pin ObjectPinInfo `json:"-"`
+
 	Writer       io.WriteCloser `json:"-"`
 	Reader       *hash.Reader   `json:"-"`
 	PutObjReader *PutObjReader  `json:"-"`
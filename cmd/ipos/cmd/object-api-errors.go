@@ -166,6 +166,12 @@ func (e BucketSSEConfigNotFound) Error() string {
 	return "No bucket encryption found for bucket: " + e.Bucket
 }
 
+type BucketPinningConfigNotFound GenericError
+
+func (e BucketPinningConfigNotFound) Error() string {
+	return "No bucket pinning configuration found for bucket: " + e.Bucket
+}
+
 type BucketNameInvalid GenericError
 
 func (e BucketNameInvalid) Error() string {
@@ -249,6 +255,16 @@ func (e InvalidUploadID) Error() string {
 	return "Invalid upload id " + e.UploadID
 }
 
+type InvalidCID struct {
+	Bucket string
+	Object string
+	CID    string
+}
+
+func (e InvalidCID) Error() string {
+	return "CID " + e.CID + " does not reference a file"
+}
+
 type InvalidPart struct {
 	PartNumber int
 	ExpETag    string
@@ -3,9 +3,30 @@ package cmd
 import (
 	"context"
 
+	"github.com/google/uuid"
+
 	"github.com/storeros/ipos/cmd/ipos/logger"
 )
 
+func checkObjectArgs(ctx context.Context, bucket, object string, obj ObjectLayer) error {
+	if err := checkBucketExist(ctx, bucket, obj); err != nil {
+		return err
+	}
+
+	if err := checkObjectNameForLengthAndSlash(bucket, object); err != nil {
+		return err
+	}
+
+	if !IsValidObjectName(object) {
+		return ObjectNameInvalid{
+			Bucket: bucket,
+			Object: object,
+		}
+	}
+
+	return nil
+}
+
 func checkListObjsArgs(ctx context.Context, bucket, prefix, marker string, obj ObjectLayer) error {
 	if err := checkBucketExist(ctx, bucket, obj); err != nil {
 		return err
@@ -40,3 +61,48 @@ func checkBucketExist(ctx context.Context, bucket string, obj ObjectLayer) error
 	}
 	return nil
 }
+
+func checkListMultipartArgs(ctx context.Context, bucket, prefix, keyMarker, uploadIDMarker, delimiter string, obj ObjectLayer) error {
+	if err := checkListObjsArgs(ctx, bucket, prefix, keyMarker, obj); err != nil {
+		return err
+	}
+	if uploadIDMarker != "" {
+		if HasSuffix(keyMarker, SlashSeparator) {
+			logger.LogIf(ctx, InvalidUploadIDKeyCombination{
+				UploadIDMarker: uploadIDMarker,
+				KeyMarker:      keyMarker,
+			})
+			return InvalidUploadIDKeyCombination{
+				UploadIDMarker: uploadIDMarker,
+				KeyMarker:      keyMarker,
+			}
+		}
+		if _, err := uuid.Parse(uploadIDMarker); err != nil {
+			logger.LogIf(ctx, err)
+			return MalformedUploadID{
+				UploadID: uploadIDMarker,
+			}
+		}
+	}
+	return nil
+}
+
+func checkNewMultipartArgs(ctx context.Context, bucket, object string, obj ObjectLayer) error {
+	return checkObjectArgs(ctx, bucket, object, obj)
+}
+
+func checkPutObjectPartArgs(ctx context.Context, bucket, object string, obj ObjectLayer) error {
+	return checkObjectArgs(ctx, bucket, object, obj)
+}
+
+func checkListPartsArgs(ctx context.Context, bucket, object string, obj ObjectLayer) error {
+	return checkObjectArgs(ctx, bucket, object, obj)
+}
+
+func checkCompleteMultipartArgs(ctx context.Context, bucket, object string, obj ObjectLayer) error {
+	return checkObjectArgs(ctx, bucket, object, obj)
+}
+
+func checkAbortMultipartArgs(ctx context.Context, bucket, object string, obj ObjectLayer) error {
+	return checkObjectArgs(ctx, bucket, object, obj)
+}
@@ -8,6 +8,7 @@ import (
 	bucketsse "github.com/storeros/ipos/pkg/bucket/encryption"
 	"github.com/storeros/ipos/pkg/bucket/lifecycle"
 	"github.com/storeros/ipos/pkg/bucket/object/tagging"
+	"github.com/storeros/ipos/pkg/bucket/pinning"
 	"github.com/storeros/ipos/pkg/bucket/policy"
 	"github.com/storeros/ipos/pkg/encrypt"
 	"github.com/storeros/ipos/pkg/madmin"
@@ -22,6 +23,7 @@ type ObjectOptions struct {
 	UserDefined          map[string]string
 	PartNumber           int
 	CheckCopyPrecondFn   CheckCopyPreconditionFn
+	CompressionIndexFn   func() []byte
 }
 
 type LockType int
@@ -69,6 +71,8 @@ type ObjectLayer interface {
 
 	ListBucketsHeal(ctx context.Context) (buckets []BucketInfo, err error)
 
+	GetMetrics(ctx context.Context) (*Metrics, error)
+
 	SetBucketPolicy(context.Context, string, *policy.Policy) error
 	GetBucketPolicy(context.Context, string) (*policy.Policy, error)
 	DeleteBucketPolicy(context.Context, string) error
@@ -87,6 +91,13 @@ type ObjectLayer interface {
 	GetBucketSSEConfig(context.Context, string) (*bucketsse.BucketSSEConfig, error)
 	DeleteBucketSSEConfig(context.Context, string) error
 
+	SetBucketPinning(context.Context, string, *pinning.Config) error
+	GetBucketPinning(context.Context, string) (*pinning.Config, error)
+	DeleteBucketPinning(context.Context, string) error
+	GetObjectPinInfo(context.Context, string, string) (ObjectPinInfo, error)
+
+	ImportObject(ctx context.Context, bucket, object, cid string, opts ObjectOptions) (objInfo ObjectInfo, err error)
+
 	IsReady(ctx context.Context) bool
 
 	PutObjectTag(context.Context, string, string, string) error
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/hex"
 	"fmt"
 	"io"
@@ -18,6 +19,7 @@ import (
 	"github.com/klauspost/compress/s2"
 	"github.com/klauspost/readahead"
 
+	"github.com/storeros/ipos/cmd/ipos/config/compress"
 	"github.com/storeros/ipos/cmd/ipos/crypto"
 	xhttp "github.com/storeros/ipos/cmd/ipos/http"
 	"github.com/storeros/ipos/cmd/ipos/logger"
@@ -36,6 +38,8 @@ const (
 	compReadAheadSize       = 100 << 20
 	compReadAheadBuffers    = 5
 	compReadAheadBufSize    = 1 << 20
+	compIndexInterval       = 4 << 20
+	s2StreamHeader          = "\xff\x06\x00\x00S2sTwO"
 )
 
 func isIPOSMetaBucketName(bucket string) bool {
@@ -283,6 +287,39 @@ func hasPattern(patterns []string, matchStr string) bool {
 	return false
 }
 
+func isCompressible(header http.Header, object string) bool {
+	if crypto.IsRequested(header) || excludeForCompression(header, object, globalCompressConfig) {
+		return false
+	}
+	return true
+}
+
+func excludeForCompression(header http.Header, object string, cfg compress.Config) bool {
+	if !cfg.Enabled || HasSuffix(object, SlashSeparator) {
+		return true
+	}
+
+	contentType := header.Get(xhttp.ContentType)
+	if hasStringSuffixInSlice(object, standardExcludeCompressExtensions) || hasPattern(standardExcludeCompressContentTypes, contentType) {
+		return true
+	}
+
+	if hasStringSuffixInSlice(object, cfg.ExcludeExtensions) || hasPattern(cfg.ExcludeMimeTypes, contentType) {
+		return true
+	}
+
+	if len(cfg.Extensions) == 0 && len(cfg.MimeTypes) == 0 {
+		return false
+	}
+
+	return !hasStringSuffixInSlice(object, cfg.Extensions) && !hasPattern(cfg.MimeTypes, contentType)
+}
+
+func removeCompressionMetadata(metadata map[string]string) {
+	delete(metadata, ReservedMetadataPrefix+"compression")
+	delete(metadata, ReservedMetadataPrefix+"actual-size")
+}
+
 func getPartFile(entries []string, partNumber int, etag string) string {
 	for _, entry := range entries {
 		if strings.HasPrefix(entry, fmt.Sprintf("%.5d.%s.", partNumber, etag)) {
@@ -405,6 +442,16 @@ func NewGetObjectReader(rs *HTTPRangeSpec, oi ObjectInfo, opts ObjectOptions, cl
 		}
 		off, length = int64(0), oi.Size
 		decOff, decLength := int64(0), actualSize
+		if rs != nil {
+			decOff, decLength, err = rs.GetOffsetLength(actualSize)
+			if err != nil {
+				return nil, 0, 0, err
+			}
+
+			off, decOff = getCompressedOffsets(oi, decOff)
+			length = oi.Size - off
+		}
+		seekOff := off
 		fn = func(inputReader io.Reader, _ http.Header, pcfn CheckCopyPreconditionFn, cFns ...func()) (r *GetObjectReader, err error) {
 			cFns = append(cleanUpFns, cFns...)
 			if opts.CheckCopyPrecondFn != nil {
@@ -415,6 +462,9 @@ func NewGetObjectReader(rs *HTTPRangeSpec, oi ObjectInfo, opts ObjectOptions, cl
 					return nil, PreConditionFailed{}
 				}
 			}
+			if seekOff > 0 {
+				inputReader = io.MultiReader(strings.NewReader(s2StreamHeader), inputReader)
+			}
 			s2Reader := s2.NewReader(inputReader)
 			err = s2Reader.Skip(decOff)
 			if err != nil {
@@ -555,24 +605,81 @@ func CleanIPOSInternalMetadataKeys(metadata map[string]string) map[string]string
 	return newMeta
 }
 
-func newS2CompressReader(r io.Reader) io.ReadCloser {
+func newS2CompressReader(r io.Reader) (io.ReadCloser, func() []byte) {
 	pr, pw := io.Pipe()
-	comp := s2.NewWriter(pw)
+	cw := &countingWriter{w: pw}
+	comp := s2.NewWriter(cw)
+
+	var index []byte
 	go func() {
-		_, err := io.Copy(comp, r)
-		if err != nil {
-			comp.Close()
-			pw.CloseWithError(err)
-			return
+		var actualSize int64
+		var buf [2 * binary.MaxVarintLen64]byte
+		for {
+			n, err := io.CopyN(comp, r, compIndexInterval)
+			actualSize += n
+			if err == io.EOF {
+				break
+			}
+			if err == nil {
+				err = comp.Flush()
+			}
+			if err != nil {
+				comp.Close()
+				pw.CloseWithError(err)
+				return
+			}
+			l := binary.PutUvarint(buf[:], uint64(actualSize))
+			l += binary.PutUvarint(buf[l:], uint64(cw.n))
+			index = append(index, buf[:l]...)
 		}
-		err = comp.Close()
-		if err != nil {
+		if err := comp.Close(); err != nil {
 			pw.CloseWithError(err)
 			return
 		}
 		pw.Close()
 	}()
-	return pr
+	return pr, func() []byte { return index }
+}
+
+func getCompressedOffsets(objectInfo ObjectInfo, offset int64) (compressedOffset int64, skipLength int64) {
+	if len(objectInfo.Parts) > 0 {
+		var cumulativeActualSize int64
+		for _, part := range objectInfo.Parts {
+			if cumulativeActualSize+part.ActualSize > offset {
+				break
+			}
+			cumulativeActualSize += part.ActualSize
+			compressedOffset += part.Size
+		}
+		return compressedOffset, offset - cumulativeActualSize
+	}
+
+	var actualOffset int64
+	index := objectInfo.CompressionIndex
+	for len(index) > 0 {
+		uoff, n := binary.Uvarint(index)
+		if n <= 0 {
+			break
+		}
+		coff, m := binary.Uvarint(index[n:])
+		if m <= 0 || int64(uoff) > offset {
+			break
+		}
+		actualOffset, compressedOffset = int64(uoff), int64(coff)
+		index = index[n+m:]
+	}
+	return compressedOffset, offset - actualOffset
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
 }
 
 type detectDisconnect struct {
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/s2"
+)
+
+func TestGetCompressedOffsets(t *testing.T) {
+	var index []byte
+	var buf [2 * binary.MaxVarintLen64]byte
+	for _, entry := range [][2]uint64{{4 << 20, 1000}, {8 << 20, 2100}} {
+		l := binary.PutUvarint(buf[:], entry[0])
+		l += binary.PutUvarint(buf[l:], entry[1])
+		index = append(index, buf[:l]...)
+	}
+
+	testCases := []struct {
+		objInfo          ObjectInfo
+		offset           int64
+		compressedOffset int64
+		skipLength       int64
+	}{
+		// Single part objects seek through the compression index.
+		{ObjectInfo{CompressionIndex: index}, 0, 0, 0},
+		{ObjectInfo{CompressionIndex: index}, 100, 0, 100},
+		{ObjectInfo{CompressionIndex: index}, 4<<20 - 1, 0, 4<<20 - 1},
+		{ObjectInfo{CompressionIndex: index}, 4 << 20, 1000, 0},
+		{ObjectInfo{CompressionIndex: index}, 4<<20 + 10, 1000, 10},
+		{ObjectInfo{CompressionIndex: index}, 8<<20 + 10, 2100, 10},
+		{ObjectInfo{CompressionIndex: index}, 100 << 20, 2100, 92 << 20},
+		// Objects without an index are read from the start.
+		{ObjectInfo{}, 0, 0, 0},
+		{ObjectInfo{}, 5 << 20, 0, 5 << 20},
+		// A truncated index is used up to its last complete entry.
+		{ObjectInfo{CompressionIndex: index[:len(index)-1]}, 8<<20 + 10, 1000, 4<<20 + 10},
+		// Multipart objects seek to the part holding the offset.
+		{ObjectInfo{Parts: []ObjectPartInfo{{Size: 10, ActualSize: 100}, {Size: 20, ActualSize: 200}}}, 0, 0, 0},
+		{ObjectInfo{Parts: []ObjectPartInfo{{Size: 10, ActualSize: 100}, {Size: 20, ActualSize: 200}}}, 99, 0, 99},
+		{ObjectInfo{Parts: []ObjectPartInfo{{Size: 10, ActualSize: 100}, {Size: 20, ActualSize: 200}}}, 100, 10, 0},
+		{ObjectInfo{Parts: []ObjectPartInfo{{Size: 10, ActualSize: 100}, {Size: 20, ActualSize: 200}}}, 250, 10, 150},
+	}
+
+	for i, testCase := range testCases {
+		compressedOffset, skipLength := getCompressedOffsets(testCase.objInfo, testCase.offset)
+		if compressedOffset != testCase.compressedOffset || skipLength != testCase.skipLength {
+			t.Errorf("Test %d: expected (%d, %d), got (%d, %d)", i+1,
+				testCase.compressedOffset, testCase.skipLength, compressedOffset, skipLength)
+		}
+	}
+}
+
+func TestS2CompressReaderSeek(t *testing.T) {
+	data := make([]byte, 2*compIndexInterval+12345)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	s2c, indexFn := newS2CompressReader(bytes.NewReader(data))
+	compressed, err := ioutil.ReadAll(s2c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2c.Close()
+
+	objInfo := ObjectInfo{CompressionIndex: indexFn()}
+	if len(objInfo.CompressionIndex) == 0 {
+		t.Fatal("expected a compression index")
+	}
+
+	testCases := []struct {
+		offset int64
+		length int64
+	}{
+		{0, 100},
+		{1, 100},
+		{compIndexInterval - 10, 20},
+		{compIndexInterval, 100},
+		{compIndexInterval + 17, 1000},
+		{2 * compIndexInterval, 100},
+		{2*compIndexInterval + 5, 12340},
+		{int64(len(data)) - 1, 1},
+	}
+
+	for i, testCase := range testCases {
+		compressedOffset, skipLength := getCompressedOffsets(objInfo, testCase.offset)
+		if testCase.offset >= compIndexInterval && compressedOffset == 0 {
+			t.Errorf("Test %d: expected offset %d to use the compression index", i+1, testCase.offset)
+		}
+
+		var r io.Reader = bytes.NewReader(compressed[compressedOffset:])
+		if compressedOffset > 0 {
+			r = io.MultiReader(strings.NewReader(s2StreamHeader), r)
+		}
+		s2r := s2.NewReader(r)
+		if err = s2r.Skip(skipLength); err != nil {
+			t.Fatalf("Test %d: unable to skip %d bytes: %v", i+1, skipLength, err)
+		}
+
+		got := make([]byte, testCase.length)
+		if _, err = io.ReadFull(s2r, got); err != nil {
+			t.Fatalf("Test %d: unable to read: %v", i+1, err)
+		}
+		if !bytes.Equal(got, data[testCase.offset:testCase.offset+testCase.length]) {
+			t.Errorf("Test %d: data mismatch at offset %d", i+1, testCase.offset)
+		}
+	}
+}
+
+type failingReader struct {
+	err error
+}
+
+func (f failingReader) Read(p []byte) (int, error) {
+	return 0, f.err
+}
+
+func TestS2CompressReaderError(t *testing.T) {
+	errRead := errors.New("read failed")
+	s2c, _ := newS2CompressReader(failingReader{err: errRead})
+	defer s2c.Close()
+
+	if _, err := ioutil.ReadAll(s2c); err != errRead {
+		t.Fatalf("expected %v, got %v", errRead, err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/storeros/ipos/cmd/ipos/crypto"
@@ -108,44 +109,51 @@ func checkPreconditions(ctx context.Context, w http.ResponseWriter, r *http.Requ
 		}
 	}
 
-	ifModifiedSinceHeader := r.Header.Get(xhttp.IfModifiedSince)
-	if ifModifiedSinceHeader != "" {
-		if givenTime, err := time.Parse(http.TimeFormat, ifModifiedSinceHeader); err == nil {
-			if !ifModifiedSince(objInfo.ModTime, givenTime) {
-				writeHeaders()
-				w.WriteHeader(http.StatusNotModified)
-				return true
-			}
-		}
-	}
-
-	ifUnmodifiedSinceHeader := r.Header.Get(xhttp.IfUnmodifiedSince)
-	if ifUnmodifiedSinceHeader != "" {
-		if givenTime, err := time.Parse(http.TimeFormat, ifUnmodifiedSinceHeader); err == nil {
-			if ifModifiedSince(objInfo.ModTime, givenTime) {
-				writeHeaders()
-				writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrPreconditionFailed), r.URL, guessIsBrowserReq(r))
-				return true
-			}
+	writePreconditionFailed := func() {
+		writeHeaders()
+		if r.Method == http.MethodHead {
+			writeErrorResponseHeadersOnly(w, errorCodes.ToAPIErr(ErrPreconditionFailed))
+			return
 		}
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrPreconditionFailed), r.URL, guessIsBrowserReq(r))
 	}
 
 	ifMatchETagHeader := r.Header.Get(xhttp.IfMatch)
 	if ifMatchETagHeader != "" {
-		if !isETagEqual(objInfo.ETag, ifMatchETagHeader) {
-			writeHeaders()
-			writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrPreconditionFailed), r.URL, guessIsBrowserReq(r))
+		if !isETagMatch(objInfo.ETag, ifMatchETagHeader) {
+			writePreconditionFailed()
 			return true
 		}
+	} else {
+		ifUnmodifiedSinceHeader := r.Header.Get(xhttp.IfUnmodifiedSince)
+		if ifUnmodifiedSinceHeader != "" {
+			if givenTime, err := time.Parse(http.TimeFormat, ifUnmodifiedSinceHeader); err == nil {
+				if ifModifiedSince(objInfo.ModTime, givenTime) {
+					writePreconditionFailed()
+					return true
+				}
+			}
+		}
 	}
 
 	ifNoneMatchETagHeader := r.Header.Get(xhttp.IfNoneMatch)
 	if ifNoneMatchETagHeader != "" {
-		if isETagEqual(objInfo.ETag, ifNoneMatchETagHeader) {
+		if isETagMatch(objInfo.ETag, ifNoneMatchETagHeader) {
 			writeHeaders()
 			w.WriteHeader(http.StatusNotModified)
 			return true
 		}
+	} else {
+		ifModifiedSinceHeader := r.Header.Get(xhttp.IfModifiedSince)
+		if ifModifiedSinceHeader != "" {
+			if givenTime, err := time.Parse(http.TimeFormat, ifModifiedSinceHeader); err == nil {
+				if !ifModifiedSince(objInfo.ModTime, givenTime) {
+					writeHeaders()
+					w.WriteHeader(http.StatusNotModified)
+					return true
+				}
+			}
+		}
 	}
 	return false
 }
@@ -162,6 +170,16 @@ func isETagEqual(left, right string) bool {
 	return canonicalizeETag(left) == canonicalizeETag(right)
 }
 
+func isETagMatch(etag, header string) bool {
+	for _, value := range strings.Split(header, ",") {
+		value = strings.TrimSpace(value)
+		if value == "*" || isETagEqual(etag, strings.TrimPrefix(value, "W/")) {
+			return true
+		}
+	}
+	return false
+}
+
 func deleteObject(ctx context.Context, obj ObjectLayer, bucket, object string, r *http.Request) (err error) {
 	deleteObject := obj.DeleteObject
 	if err = deleteObject(ctx, bucket, object); err != nil {
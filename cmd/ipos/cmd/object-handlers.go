@@ -1,10 +1,12 @@
 package cmd
 
 import (
+	"bufio"
 	"encoding/hex"
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -15,10 +17,12 @@ import (
 	xhttp "github.com/storeros/ipos/cmd/ipos/http"
 	"github.com/storeros/ipos/cmd/ipos/logger"
 	objectlock "github.com/storeros/ipos/pkg/bucket/object/lock"
+	"github.com/storeros/ipos/pkg/bucket/object/tagging"
 	"github.com/storeros/ipos/pkg/bucket/policy"
 	"github.com/storeros/ipos/pkg/hash"
 	iampolicy "github.com/storeros/ipos/pkg/iam/policy"
 	"github.com/storeros/ipos/pkg/ioutil"
+	"github.com/storeros/ipos/pkg/sio"
 )
 
 var supportedHeadGetReqParams = map[string]string{
@@ -183,6 +187,56 @@ func (api objectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 	}
 }
 
+type putObjectReaders struct {
+	hashReader   *hash.Reader
+	actualReader *hash.Reader
+	indexFn      func() []byte
+	compressor   io.Closer
+}
+
+func newPutObjectReaders(objectAPI ObjectLayer, header http.Header, object string, reader io.Reader, size int64, md5hex, sha256hex string, metadata map[string]string) (p putObjectReaders, err error) {
+	actualSize := size
+	if objectAPI.IsCompressionSupported() && isCompressible(header, object) && size > 0 {
+		metadata[ReservedMetadataPrefix+"compression"] = compressionAlgorithmV2
+		metadata[ReservedMetadataPrefix+"actual-size"] = strconv.FormatInt(size, 10)
+
+		p.actualReader, err = hash.NewReader(reader, size, md5hex, sha256hex, actualSize, globalCLIContext.StrictS3Compat)
+		if err != nil {
+			return p, err
+		}
+
+		var s2c io.ReadCloser
+		s2c, p.indexFn = newS2CompressReader(p.actualReader)
+		p.compressor = s2c
+		reader = s2c
+		size = -1
+		md5hex = ""
+		sha256hex = ""
+	}
+
+	p.hashReader, err = hash.NewReader(reader, size, md5hex, sha256hex, actualSize, globalCLIContext.StrictS3Compat)
+	if err != nil {
+		p.Close()
+		return p, err
+	}
+	return p, nil
+}
+
+func (p putObjectReaders) PutObjReader() *PutObjReader {
+	pReader := NewPutObjReader(p.hashReader, nil, nil)
+	if p.actualReader != nil {
+		pReader.rawReader = p.actualReader
+	}
+	return pReader
+}
+
+func (p putObjectReaders) Close() error {
+	if p.compressor == nil {
+		return nil
+	}
+	return p.compressor.Close()
+}
+
 func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := newContext(r, w, "PutObject")
 	defer logger.AuditLog(w, r, "PutObject", mustGetClaimsFromToken(r))
@@ -310,19 +364,20 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 		}
 	}
 
-	if globalAutoEncryption && !crypto.SSEC.IsRequested(r.Header) && !crypto.S3KMS.IsRequested(r.Header) {
-		r.Header.Add(crypto.SSEHeader, crypto.SSEAlgorithmAES256)
+	if objectAPI.IsEncryptionSupported() && !HasSuffix(object, SlashSeparator) {
+		applyDefaultEncryption(r.Header, bucket)
 	}
 
-	actualSize := size
-	hashReader, err := hash.NewReader(reader, size, md5hex, sha256hex, actualSize, globalCLIContext.StrictS3Compat)
+	putReaders, err := newPutObjectReaders(objectAPI, r.Header, object, reader, size, md5hex, sha256hex, metadata)
 	if err != nil {
 		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
 		return
 	}
+	defer putReaders.Close()
 
+	hashReader := putReaders.hashReader
 	rawReader := hashReader
-	pReader := NewPutObjReader(rawReader, nil, nil)
+	pReader := putReaders.PutObjReader()
 
 	var opts ObjectOptions
 	opts, err = putOpts(ctx, r, bucket, object, metadata)
@@ -330,6 +385,7 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 		writeErrorResponseHeadersOnly(w, toAPIError(ctx, err))
 		return
 	}
+	opts.CompressionIndexFn = putReaders.indexFn
 
 	retPerms := isPutActionAllowed(getRequestAuthType(r), bucket, object, r, iampolicy.PutObjectRetentionAction)
 	holdPerms := isPutActionAllowed(getRequestAuthType(r), bucket, object, r, iampolicy.PutObjectLegalHoldAction)
@@ -382,13 +438,12 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 	}
 
 	etag := objInfo.ETag
-	switch {
-	case objInfo.IsCompressed():
-		if !strings.HasSuffix(objInfo.ETag, "-1") {
-			etag = objInfo.ETag + "-1"
-		}
-	case crypto.IsEncrypted(objInfo.UserDefined):
+	if crypto.IsEncrypted(objInfo.UserDefined) {
 		switch {
+		case crypto.S3KMS.IsRequested(r.Header):
+			w.Header().Set(crypto.SSEHeader, crypto.SSEAlgorithmKMS)
+			w.Header().Set(crypto.SSEKmsID, GlobalKMS.KeyID())
+			etag, _ = DecryptETag(objectEncryptionKey, ObjectInfo{ETag: etag})
 		case crypto.S3.IsEncrypted(objInfo.UserDefined):
 			w.Header().Set(crypto.SSEHeader, crypto.SSEAlgorithmAES256)
 			etag, _ = DecryptETag(objectEncryptionKey, ObjectInfo{ETag: etag})
@@ -402,6 +457,100 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 		}
 	}
 	w.Header()[xhttp.ETag] = []string{`"` + etag + `"`}
+	if objInfo.CID != "" {
+		w.Header().Set(xhttp.IPOSCID, objInfo.CID)
+	}
+	writeSuccessResponseHeadersOnly(w)
+}
+
+func (api objectAPIHandlers) ImportObjectHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "ImportObject")
+	defer logger.AuditLog(w, r, "ImportObject", mustGetClaimsFromToken(r))
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r.URL, guessIsBrowserReq(r))
+		return
+	}
+	if crypto.IsRequested(r.Header) {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrNotImplemented), r.URL, guessIsBrowserReq(r))
+		return
+	}
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object, err := url.PathUnescape(vars["object"])
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if s3Error := checkRequestAuthType(ctx, r, policy.PutObjectAction, bucket, object); s3Error != ErrNone {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Error), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	cid, err := parseCID(vars["cid"])
+	if err != nil {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErrWithErr(ErrInvalidCID, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	// Imported content is referenced as is and can not be encrypted, refuse
+	// it for buckets where every object has to be encrypted.
+	if objectAPI.IsEncryptionSupported() {
+		sseHeader := make(http.Header)
+		applyDefaultEncryption(sseHeader, bucket)
+		if crypto.IsRequested(sseHeader) {
+			writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrImportEncryptionRequired), r.URL, guessIsBrowserReq(r))
+			return
+		}
+	}
+
+	if sc := r.Header.Get(xhttp.AmzStorageClass); sc != "" {
+		if !(sc == "rrs" || sc == "standard") {
+			writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidStorageClass), r.URL, guessIsBrowserReq(r))
+			return
+		}
+	}
+
+	metadata, err := extractMetadata(ctx, r)
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if tags := r.Header.Get(xhttp.AmzObjectTagging); tags != "" {
+		metadata[xhttp.AmzObjectTagging], err = extractTags(ctx, tags)
+		if err != nil {
+			writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+			return
+		}
+	}
+
+	retPerms := isPutActionAllowed(getRequestAuthType(r), bucket, object, r, iampolicy.PutObjectRetentionAction)
+	holdPerms := isPutActionAllowed(getRequestAuthType(r), bucket, object, r, iampolicy.PutObjectLegalHoldAction)
+
+	retentionMode, retentionDate, legalHold, s3Err := checkPutObjectLockAllowed(ctx, r, bucket, object, objectAPI.GetObjectInfo, retPerms, holdPerms)
+	if s3Err == ErrNone && retentionMode.Valid() {
+		metadata[strings.ToLower(xhttp.AmzObjectLockMode)] = string(retentionMode)
+		metadata[strings.ToLower(xhttp.AmzObjectLockRetainUntilDate)] = retentionDate.UTC().Format(time.RFC3339)
+	}
+	if s3Err == ErrNone && legalHold.Status.Valid() {
+		metadata[strings.ToLower(xhttp.AmzObjectLockLegalHold)] = string(legalHold.Status)
+	}
+	if s3Err != ErrNone {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	objInfo, err := objectAPI.ImportObject(ctx, bucket, object, cid, ObjectOptions{UserDefined: metadata})
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	w.Header()[xhttp.ETag] = []string{`"` + objInfo.ETag + `"`}
+	w.Header().Set(xhttp.IPOSCID, objInfo.CID)
 	writeSuccessResponseHeadersOnly(w)
 }
 
@@ -456,3 +605,1332 @@ func (api objectAPIHandlers) DeleteObjectHandler(w http.ResponseWriter, r *http.
 
 	writeSuccessNoContent(w)
 }
+
+func (api objectAPIHandlers) NewMultipartUploadHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "NewMultipartUpload")
+
+	defer logger.AuditLog(w, r, "NewMultipartUpload", mustGetClaimsFromToken(r))
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r.URL, guessIsBrowserReq(r))
+		return
+	}
+	if crypto.S3KMS.IsRequested(r.Header) && !api.AllowSSEKMS() {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrNotImplemented), r.URL, guessIsBrowserReq(r))
+		return
+	}
+	if !api.EncryptionEnabled() && crypto.IsRequested(r.Header) {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrNotImplemented), r.URL, guessIsBrowserReq(r))
+		return
+	}
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object, err := url.PathUnescape(vars["object"])
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if s3Error := checkRequestAuthType(ctx, r, policy.PutObjectAction, bucket, object); s3Error != ErrNone {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Error), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if sc := r.Header.Get(xhttp.AmzStorageClass); sc != "" {
+		if !(sc == "rrs" || sc == "standard") {
+			writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidStorageClass), r.URL, guessIsBrowserReq(r))
+			return
+		}
+	}
+
+	metadata, err := extractMetadata(ctx, r)
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if tags := r.Header.Get(xhttp.AmzObjectTagging); tags != "" {
+		metadata[xhttp.AmzObjectTagging], err = extractTags(ctx, tags)
+		if err != nil {
+			writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+			return
+		}
+	}
+
+	retPerms := isPutActionAllowed(getRequestAuthType(r), bucket, object, r, iampolicy.PutObjectRetentionAction)
+	holdPerms := isPutActionAllowed(getRequestAuthType(r), bucket, object, r, iampolicy.PutObjectLegalHoldAction)
+
+	getObjectInfo := objectAPI.GetObjectInfo
+
+	retentionMode, retentionDate, legalHold, s3Err := checkPutObjectLockAllowed(ctx, r, bucket, object, getObjectInfo, retPerms, holdPerms)
+	if s3Err == ErrNone && retentionMode.Valid() {
+		metadata[strings.ToLower(xhttp.AmzObjectLockMode)] = string(retentionMode)
+		metadata[strings.ToLower(xhttp.AmzObjectLockRetainUntilDate)] = retentionDate.UTC().Format(time.RFC3339)
+	}
+	if s3Err == ErrNone && legalHold.Status.Valid() {
+		metadata[strings.ToLower(xhttp.AmzObjectLockLegalHold)] = string(legalHold.Status)
+	}
+	if s3Err != ErrNone {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if objectAPI.IsEncryptionSupported() && !HasSuffix(object, SlashSeparator) {
+		applyDefaultEncryption(r.Header, bucket)
+		if crypto.IsRequested(r.Header) {
+			if crypto.SSECopy.IsRequested(r.Header) {
+				writeErrorResponse(ctx, w, toAPIError(ctx, errInvalidEncryptionParameters), r.URL, guessIsBrowserReq(r))
+				return
+			}
+			if err = setEncryptionMetadata(r, bucket, object, metadata); err != nil {
+				writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+				return
+			}
+			metadata[crypto.SSEMultipart] = ""
+		}
+	}
+
+	if objectAPI.IsCompressionSupported() && isCompressible(r.Header, object) {
+		metadata[ReservedMetadataPrefix+"compression"] = compressionAlgorithmV2
+	}
+
+	opts, err := putOpts(ctx, r, bucket, object, metadata)
+	if err != nil {
+		writeErrorResponseHeadersOnly(w, toAPIError(ctx, err))
+		return
+	}
+
+	newMultipartUpload := objectAPI.NewMultipartUpload
+
+	uploadID, err := newMultipartUpload(ctx, bucket, object, opts)
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	switch {
+	case crypto.S3KMS.IsRequested(r.Header):
+		w.Header().Set(crypto.SSEHeader, crypto.SSEAlgorithmKMS)
+		w.Header().Set(crypto.SSEKmsID, GlobalKMS.KeyID())
+	case crypto.S3.IsEncrypted(metadata):
+		w.Header().Set(crypto.SSEHeader, crypto.SSEAlgorithmAES256)
+	case crypto.SSEC.IsEncrypted(metadata):
+		w.Header().Set(crypto.SSECAlgorithm, r.Header.Get(crypto.SSECAlgorithm))
+		w.Header().Set(crypto.SSECKeyMD5, r.Header.Get(crypto.SSECKeyMD5))
+	}
+
+	response := generateInitiateMultipartUploadResponse(bucket, object, uploadID)
+	encodedSuccessResponse := encodeResponse(response)
+
+	writeSuccessResponseXML(w, encodedSuccessResponse)
+}
+
+func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "PutObjectPart")
+
+	defer logger.AuditLog(w, r, "PutObjectPart", mustGetClaimsFromToken(r))
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r.URL, guessIsBrowserReq(r))
+		return
+	}
+	if crypto.S3KMS.IsRequested(r.Header) && !api.AllowSSEKMS() {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrNotImplemented), r.URL, guessIsBrowserReq(r))
+		return
+	}
+	if !api.EncryptionEnabled() && crypto.IsRequested(r.Header) {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrNotImplemented), r.URL, guessIsBrowserReq(r))
+		return
+	}
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object, err := url.PathUnescape(vars["object"])
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	r.Body = &detectDisconnect{r.Body, r.Context().Done()}
+
+	if _, ok := r.Header[xhttp.AmzCopySource]; ok {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidCopySource), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	md5Bytes, err := checkValidMD5(r.Header)
+	if err != nil {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidDigest), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	size := r.ContentLength
+	rAuthType := getRequestAuthType(r)
+	if rAuthType == authTypeStreamingSigned {
+		if sizeStr, ok := r.Header[xhttp.AmzDecodedContentLength]; ok {
+			if sizeStr[0] == "" {
+				writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrMissingContentLength), r.URL, guessIsBrowserReq(r))
+				return
+			}
+			size, err = strconv.ParseInt(sizeStr[0], 10, 64)
+			if err != nil {
+				writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+				return
+			}
+		}
+	}
+	if size == -1 {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrMissingContentLength), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if isMaxAllowedPartSize(size) {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrEntityTooLarge), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	uploadID := r.URL.Query().Get("uploadId")
+	partIDString := r.URL.Query().Get("partNumber")
+
+	partID, err := strconv.Atoi(partIDString)
+	if err != nil {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidPart), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if isMaxPartID(partID) {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidMaxParts), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	var (
+		md5hex    = hex.EncodeToString(md5Bytes)
+		sha256hex = ""
+		reader    io.Reader
+		s3Error   APIErrorCode
+	)
+	reader = r.Body
+
+	if s3Error = isPutActionAllowed(rAuthType, bucket, object, r, iampolicy.PutObjectAction); s3Error != ErrNone {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Error), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	switch rAuthType {
+	case authTypeStreamingSigned:
+		reader, s3Error = newSignV4ChunkedReader(r)
+		if s3Error != ErrNone {
+			writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Error), r.URL, guessIsBrowserReq(r))
+			return
+		}
+	case authTypeSigned:
+		if s3Error = reqSignatureV4Verify(r, globalServerRegion, serviceS3); s3Error != ErrNone {
+			writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Error), r.URL, guessIsBrowserReq(r))
+			return
+		}
+		if !skipContentSha256Cksum(r) {
+			sha256hex = getContentSha256Cksum(r, serviceS3)
+		}
+	}
+
+	var li ListPartsInfo
+	if objectAPI.IsEncryptionSupported() || objectAPI.IsCompressionSupported() {
+		li, err = objectAPI.ListObjectParts(ctx, bucket, object, uploadID, 0, 1, ObjectOptions{})
+		if err != nil {
+			writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+			return
+		}
+	}
+
+	var actualReader *hash.Reader
+	actualSize := size
+	if _, ok := li.UserDefined[ReservedMetadataPrefix+"compression"]; ok && objectAPI.IsCompressionSupported() {
+		actualReader, err = hash.NewReader(reader, size, md5hex, sha256hex, actualSize, globalCLIContext.StrictS3Compat)
+		if err != nil {
+			writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+			return
+		}
+
+		s2c, _ := newS2CompressReader(actualReader)
+		defer s2c.Close()
+		reader = s2c
+		size = -1
+		md5hex = ""
+		sha256hex = ""
+	}
+
+	hashReader, err := hash.NewReader(reader, size, md5hex, sha256hex, actualSize, globalCLIContext.StrictS3Compat)
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	rawReader := hashReader
+	pReader := NewPutObjReader(rawReader, nil, nil)
+	if actualReader != nil {
+		pReader.rawReader = actualReader
+	}
+
+	var (
+		opts                ObjectOptions
+		isEncrypted         bool
+		objectEncryptionKey crypto.ObjectKey
+	)
+	if objectAPI.IsEncryptionSupported() {
+		if crypto.IsEncrypted(li.UserDefined) {
+			if !crypto.SSEC.IsRequested(r.Header) && crypto.SSEC.IsEncrypted(li.UserDefined) {
+				writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrSSEMultipartEncrypted), r.URL, guessIsBrowserReq(r))
+				return
+			}
+			if crypto.SSEC.IsRequested(r.Header) && crypto.S3.IsEncrypted(li.UserDefined) {
+				writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrSSEMultipartEncrypted), r.URL, guessIsBrowserReq(r))
+				return
+			}
+			isEncrypted = true
+
+			objectEncryptionKey, err = getMultipartObjectKey(r.Header, bucket, object, li.UserDefined)
+			if err != nil {
+				writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+				return
+			}
+
+			in := io.Reader(hashReader)
+			if size > encryptBufferThreshold {
+				in = bufio.NewReaderSize(hashReader, encryptBufferSize)
+			}
+			partKey := objectEncryptionKey.DerivePartKey(uint32(partID))
+			reader, err = sio.EncryptReader(in, sio.Config{Key: partKey[:], MinVersion: sio.Version20})
+			if err != nil {
+				writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+				return
+			}
+
+			info := ObjectInfo{Size: size}
+			hashReader, err = hash.NewReader(reader, info.EncryptedSize(), "", "", size, globalCLIContext.StrictS3Compat)
+			if err != nil {
+				writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+				return
+			}
+			pReader = NewPutObjReader(rawReader, hashReader, &objectEncryptionKey)
+		}
+	}
+
+	putObjectPart := objectAPI.PutObjectPart
+
+	partInfo, err := putObjectPart(ctx, bucket, object, uploadID, partID, pReader, opts)
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	etag := partInfo.ETag
+	if isEncrypted {
+		etag = tryDecryptETag(objectEncryptionKey[:], partInfo.ETag, crypto.SSEC.IsRequested(r.Header))
+	}
+	w.Header()[xhttp.ETag] = []string{`"` + etag + `"`}
+
+	writeSuccessResponseHeadersOnly(w)
+}
+
+func (api objectAPIHandlers) AbortMultipartUploadHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "AbortMultipartUpload")
+
+	defer logger.AuditLog(w, r, "AbortMultipartUpload", mustGetClaimsFromToken(r))
+
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object, err := url.PathUnescape(vars["object"])
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if s3Error := checkRequestAuthType(ctx, r, policy.AbortMultipartUploadAction, bucket, object); s3Error != ErrNone {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Error), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	uploadID, _, _, _, s3Error := getObjectResources(r.URL.Query())
+	if s3Error != ErrNone {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Error), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	abortMultipartUpload := objectAPI.AbortMultipartUpload
+
+	if err := abortMultipartUpload(ctx, bucket, object, uploadID); err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	writeSuccessNoContent(w)
+}
+
+func (api objectAPIHandlers) ListObjectPartsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "ListObjectParts")
+
+	defer logger.AuditLog(w, r, "ListObjectParts", mustGetClaimsFromToken(r))
+
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object, err := url.PathUnescape(vars["object"])
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if s3Error := checkRequestAuthType(ctx, r, policy.ListMultipartUploadPartsAction, bucket, object); s3Error != ErrNone {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Error), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	uploadID, partNumberMarker, maxParts, encodingType, s3Error := getObjectResources(r.URL.Query())
+	if s3Error != ErrNone {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Error), r.URL, guessIsBrowserReq(r))
+		return
+	}
+	if partNumberMarker < 0 {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidPartNumberMarker), r.URL, guessIsBrowserReq(r))
+		return
+	}
+	if maxParts < 0 {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidMaxParts), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	listPartsInfo, err := objectAPI.ListObjectParts(ctx, bucket, object, uploadID, partNumberMarker, maxParts, ObjectOptions{})
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if objectAPI.IsEncryptionSupported() && crypto.IsEncrypted(listPartsInfo.UserDefined) {
+		var objectEncryptionKey []byte
+		ssec := crypto.SSEC.IsEncrypted(listPartsInfo.UserDefined)
+		if !ssec {
+			objectEncryptionKey, err = decryptObjectInfo(nil, bucket, object, listPartsInfo.UserDefined)
+			if err != nil {
+				writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+				return
+			}
+		}
+		for i, part := range listPartsInfo.Parts {
+			listPartsInfo.Parts[i].ETag = tryDecryptETag(objectEncryptionKey, part.ETag, ssec)
+			listPartsInfo.Parts[i].Size = part.ActualSize
+		}
+	}
+
+	if _, ok := listPartsInfo.UserDefined[ReservedMetadataPrefix+"compression"]; ok {
+		for i, part := range listPartsInfo.Parts {
+			listPartsInfo.Parts[i].Size = part.ActualSize
+		}
+	}
+
+	response := generateListPartsResponse(listPartsInfo, encodingType)
+	encodedSuccessResponse := encodeResponse(response)
+
+	writeSuccessResponseXML(w, encodedSuccessResponse)
+}
+
+func (api objectAPIHandlers) CompleteMultipartUploadHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "CompleteMultipartUpload")
+
+	defer logger.AuditLog(w, r, "CompleteMultipartUpload", mustGetClaimsFromToken(r))
+
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object, err := url.PathUnescape(vars["object"])
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if s3Error := checkRequestAuthType(ctx, r, policy.PutObjectAction, bucket, object); s3Error != ErrNone {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Error), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	uploadID, _, _, _, s3Error := getObjectResources(r.URL.Query())
+	if s3Error != ErrNone {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Error), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	complMultipartUpload := &CompleteMultipartUpload{}
+	if err = xmlDecoder(r.Body, complMultipartUpload, r.ContentLength); err != nil {
+		if err == io.EOF {
+			writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrMalformedXML), r.URL, guessIsBrowserReq(r))
+			return
+		}
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+	if len(complMultipartUpload.Parts) == 0 {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrMalformedXML), r.URL, guessIsBrowserReq(r))
+		return
+	}
+	if !sort.IsSorted(CompletedParts(complMultipartUpload.Parts)) {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidPartOrder), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	var (
+		isEncrypted, ssec   bool
+		objectEncryptionKey []byte
+		partsMap            map[int]PartInfo
+	)
+	if objectAPI.IsEncryptionSupported() {
+		li, err := objectAPI.ListObjectParts(ctx, bucket, object, uploadID, 0, maxPartsList, ObjectOptions{})
+		if err != nil {
+			writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+			return
+		}
+		if crypto.IsEncrypted(li.UserDefined) {
+			isEncrypted = true
+			ssec = crypto.SSEC.IsEncrypted(li.UserDefined)
+			if !ssec {
+				objectEncryptionKey, err = decryptObjectInfo(nil, bucket, object, li.UserDefined)
+				if err != nil {
+					writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+					return
+				}
+			}
+			partsMap = make(map[int]PartInfo, len(li.Parts))
+			for _, part := range li.Parts {
+				partsMap[part.PartNumber] = part
+			}
+		}
+	}
+
+	completeParts := make([]CompletePart, len(complMultipartUpload.Parts))
+	for i, part := range complMultipartUpload.Parts {
+		part.ETag = canonicalizeETag(part.ETag)
+		if isEncrypted {
+			if bkPartInfo, ok := partsMap[part.PartNumber]; ok {
+				if tryDecryptETag(objectEncryptionKey, bkPartInfo.ETag, ssec) != part.ETag {
+					writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidPart), r.URL, guessIsBrowserReq(r))
+					return
+				}
+				part.ETag = bkPartInfo.ETag
+			}
+		}
+		completeParts[i] = part
+	}
+
+	completeMultiPartUpload := objectAPI.CompleteMultipartUpload
+
+	objInfo, err := completeMultiPartUpload(ctx, bucket, object, uploadID, completeParts, ObjectOptions{})
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	location := getObjectLocation(r, globalDomainNames, bucket, object)
+	response := generateCompleteMultpartUploadResponse(bucket, object, location, objInfo.ETag)
+	encodedSuccessResponse := encodeResponse(response)
+
+	w.Header()[xhttp.ETag] = []string{`"` + objInfo.ETag + `"`}
+	if objInfo.CID != "" {
+		w.Header().Set(xhttp.IPOSCID, objInfo.CID)
+	}
+
+	writeSuccessResponseXML(w, encodedSuccessResponse)
+}
+
+func (api objectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "CopyObject")
+
+	defer logger.AuditLog(w, r, "CopyObject", mustGetClaimsFromToken(r))
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r.URL, guessIsBrowserReq(r))
+		return
+	}
+	if crypto.S3KMS.IsRequested(r.Header) && !api.AllowSSEKMS() {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrNotImplemented), r.URL, guessIsBrowserReq(r))
+		return
+	}
+	if !api.EncryptionEnabled() && crypto.IsRequested(r.Header) {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrNotImplemented), r.URL, guessIsBrowserReq(r))
+		return
+	}
+	vars := mux.Vars(r)
+	dstBucket := vars["bucket"]
+	dstObject, err := url.PathUnescape(vars["object"])
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if s3Error := checkRequestAuthType(ctx, r, policy.PutObjectAction, dstBucket, dstObject); s3Error != ErrNone {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Error), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	cpSrcPath, err := url.QueryUnescape(r.Header.Get(xhttp.AmzCopySource))
+	if err != nil {
+		cpSrcPath = r.Header.Get(xhttp.AmzCopySource)
+	}
+	if u, err := url.Parse(cpSrcPath); err == nil {
+		if vid := u.Query().Get("versionId"); vid != "" && vid != "null" {
+			writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrNoSuchVersion), r.URL, guessIsBrowserReq(r))
+			return
+		}
+		cpSrcPath = u.Path
+	}
+
+	srcBucket, srcObject := path2BucketObject(cpSrcPath)
+	if srcObject == "" || srcBucket == "" {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidCopySource), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if s3Error := checkRequestAuthType(ctx, r, policy.GetObjectAction, srcBucket, srcObject); s3Error != ErrNone {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Error), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if !isDirectiveValid(r.Header.Get(xhttp.AmzMetadataDirective)) {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidMetadataDirective), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if sc := r.Header.Get(xhttp.AmzStorageClass); sc != "" {
+		if !(sc == "rrs" || sc == "standard") {
+			writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidStorageClass), r.URL, guessIsBrowserReq(r))
+			return
+		}
+	}
+
+	cpSrcDstSame := isStringEqual(pathJoin(srcBucket, srcObject), pathJoin(dstBucket, dstObject))
+
+	if objectAPI.IsEncryptionSupported() && !HasSuffix(dstObject, SlashSeparator) {
+		applyDefaultEncryption(r.Header, dstBucket)
+	}
+
+	srcOpts, err := copySrcOpts(ctx, r, srcBucket, srcObject)
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	dstOpts, err := copyDstOpts(ctx, r, dstBucket, dstObject, nil)
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	srcOpts.CheckCopyPrecondFn = func(o ObjectInfo, encETag string) bool {
+		return checkCopyObjectPreconditions(ctx, w, r, o, encETag)
+	}
+
+	getObjectInfo := objectAPI.GetObjectInfo
+
+	srcInfo, err := getObjectInfo(ctx, srcBucket, srcObject, srcOpts)
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if isMaxObjectSize(srcInfo.Size) {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrEntityTooLarge), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	metadata := make(map[string]string)
+	for k, v := range srcInfo.UserDefined {
+		metadata[k] = v
+	}
+	srcTags := srcInfo.UserTags
+
+	if isDirectiveReplace(r.Header.Get(xhttp.AmzMetadataDirective)) {
+		metadata, err = extractMetadata(ctx, r)
+		if err != nil {
+			writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+			return
+		}
+		srcInfo.ContentType = metadata["content-type"]
+		if srcInfo.IsCompressed() {
+			metadata[ReservedMetadataPrefix+"compression"] = srcInfo.UserDefined[ReservedMetadataPrefix+"compression"]
+			metadata[ReservedMetadataPrefix+"actual-size"] = srcInfo.UserDefined[ReservedMetadataPrefix+"actual-size"]
+		}
+	}
+
+	delete(metadata, xhttp.AmzObjectTagging)
+	if isDirectiveReplace(r.Header.Get(xhttp.AmzTagDirective)) {
+		srcTags, err = extractTags(ctx, r.Header.Get(xhttp.AmzObjectTagging))
+		if err != nil {
+			writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+			return
+		}
+	}
+	if srcTags != "" {
+		metadata[xhttp.AmzObjectTagging] = srcTags
+	}
+
+	srcInfo.metadataOnly = cpSrcDstSame
+
+	var (
+		isSourceEncrypted, isTargetEncrypted bool
+		sseS3, sseC, sseCopyS3, sseCopyC     bool
+	)
+	if objectAPI.IsEncryptionSupported() {
+		if !crypto.IsEncrypted(srcInfo.UserDefined) && crypto.SSECopy.IsRequested(r.Header) {
+			writeErrorResponse(ctx, w, toAPIError(ctx, errInvalidEncryptionParameters), r.URL, guessIsBrowserReq(r))
+			return
+		}
+		if crypto.SSEC.IsEncrypted(srcInfo.UserDefined) && !crypto.SSECopy.IsRequested(r.Header) {
+			writeErrorResponse(ctx, w, toAPIError(ctx, errEncryptedObject), r.URL, guessIsBrowserReq(r))
+			return
+		}
+
+		if sseS3, err = parseKMSRequest(r.Header); err != nil {
+			writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+			return
+		}
+		sseC = crypto.SSEC.IsRequested(r.Header)
+		if sseS3 && sseC {
+			writeErrorResponse(ctx, w, toAPIError(ctx, crypto.ErrIncompatibleEncryptionMethod), r.URL, guessIsBrowserReq(r))
+			return
+		}
+		sseCopyS3 = crypto.S3.IsEncrypted(srcInfo.UserDefined)
+		sseCopyC = crypto.SSEC.IsEncrypted(srcInfo.UserDefined)
+
+		isSourceEncrypted = sseCopyS3 || sseCopyC
+		isTargetEncrypted = sseS3 || sseC
+	}
+
+	if cpSrcDstSame && !isDirectiveReplace(r.Header.Get(xhttp.AmzMetadataDirective)) && !isSourceEncrypted && !isTargetEncrypted {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidCopyDest), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	encMetadata := make(map[string]string)
+	if isSourceEncrypted || isTargetEncrypted {
+		var newKey []byte
+		if sseC {
+			newKey, err = ParseSSECustomerRequest(r)
+			if err != nil {
+				writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+				return
+			}
+		}
+
+		if cpSrcDstSame && ((sseCopyC && sseC) || (sseCopyS3 && sseS3)) {
+			var oldKey []byte
+			if sseCopyC {
+				oldKey, err = ParseSSECopyCustomerRequest(r.Header, srcInfo.UserDefined)
+				if err != nil {
+					writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+					return
+				}
+			}
+
+			for k, v := range srcInfo.UserDefined {
+				if HasPrefix(k, ReservedMetadataPrefix) {
+					encMetadata[k] = v
+				}
+			}
+			if err = rotateKey(oldKey, newKey, srcBucket, srcObject, encMetadata); err != nil {
+				writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+				return
+			}
+			srcInfo.metadataOnly = true
+		} else {
+			var lock = noLock
+			if !cpSrcDstSame {
+				lock = readLock
+			}
+
+			gr, err := objectAPI.GetObjectNInfo(ctx, srcBucket, srcObject, nil, r.Header, lock, srcOpts)
+			if err != nil {
+				if isErrPreconditionFailed(err) {
+					return
+				}
+				writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+				return
+			}
+			defer gr.Close()
+
+			actualSize := srcInfo.Size
+			switch {
+			case isSourceEncrypted:
+				if actualSize, err = srcInfo.DecryptedSize(); err != nil {
+					writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+					return
+				}
+			case srcInfo.IsCompressed():
+				if actualSize = srcInfo.GetActualSize(); actualSize < 0 {
+					writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidDecompressedSize), r.URL, guessIsBrowserReq(r))
+					return
+				}
+			}
+			removeCompressionMetadata(metadata)
+
+			rawReader, err := hash.NewReader(gr, actualSize, "", "", actualSize, globalCLIContext.StrictS3Compat)
+			if err != nil {
+				writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+				return
+			}
+			pReader := NewPutObjReader(rawReader, nil, nil)
+
+			if !isTargetEncrypted && objectAPI.IsCompressionSupported() && isCompressible(r.Header, dstObject) && actualSize > 0 {
+				metadata[ReservedMetadataPrefix+"compression"] = compressionAlgorithmV2
+				metadata[ReservedMetadataPrefix+"actual-size"] = strconv.FormatInt(actualSize, 10)
+
+				var s2c io.ReadCloser
+				s2c, dstOpts.CompressionIndexFn = newS2CompressReader(rawReader)
+				defer s2c.Close()
+
+				compReader, err := hash.NewReader(s2c, -1, "", "", actualSize, globalCLIContext.StrictS3Compat)
+				if err != nil {
+					writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+					return
+				}
+				pReader = NewPutObjReader(compReader, nil, nil)
+				pReader.rawReader = rawReader
+			}
+
+			if isTargetEncrypted {
+				reader, objectEncryptionKey, err := newEncryptReader(rawReader, newKey, dstBucket, dstObject, encMetadata, sseS3)
+				if err != nil {
+					writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+					return
+				}
+				info := ObjectInfo{Size: actualSize}
+				encReader, err := hash.NewReader(reader, info.EncryptedSize(), "", "", actualSize, globalCLIContext.StrictS3Compat)
+				if err != nil {
+					writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+					return
+				}
+				pReader = NewPutObjReader(rawReader, encReader, &objectEncryptionKey)
+			}
+
+			crypto.RemoveInternalEntries(metadata)
+			srcInfo.PutObjReader = pReader
+			srcInfo.metadataOnly = false
+			srcOpts.CheckCopyPrecondFn = nil
+		}
+	}
+
+	for k, v := range encMetadata {
+		metadata[k] = v
+	}
+	crypto.RemoveSensitiveEntries(metadata)
+
+	srcInfo.UserDefined = metadata
+	dstOpts.UserDefined = metadata
+
+	copyObject := objectAPI.CopyObject
+
+	objInfo, err := copyObject(ctx, srcBucket, srcObject, dstBucket, dstObject, srcInfo, srcOpts, dstOpts)
+	if err != nil {
+		if isErrPreconditionFailed(err) {
+			return
+		}
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	switch {
+	case crypto.S3KMS.IsRequested(r.Header):
+		w.Header().Set(crypto.SSEHeader, crypto.SSEAlgorithmKMS)
+		w.Header().Set(crypto.SSEKmsID, GlobalKMS.KeyID())
+	case crypto.S3.IsEncrypted(objInfo.UserDefined):
+		w.Header().Set(crypto.SSEHeader, crypto.SSEAlgorithmAES256)
+	case crypto.SSEC.IsEncrypted(objInfo.UserDefined):
+		w.Header().Set(crypto.SSECAlgorithm, r.Header.Get(crypto.SSECAlgorithm))
+		w.Header().Set(crypto.SSECKeyMD5, r.Header.Get(crypto.SSECKeyMD5))
+	}
+	objInfo.ETag = getDecryptedETag(r.Header, objInfo, false)
+
+	if objInfo.CID != "" {
+		w.Header().Set(xhttp.IPOSCID, objInfo.CID)
+	}
+
+	response := generateCopyObjectResponse(objInfo.ETag, objInfo.ModTime)
+	encodedSuccessResponse := encodeResponse(response)
+
+	writeSuccessResponseXML(w, encodedSuccessResponse)
+}
+
+func (api objectAPIHandlers) CopyObjectPartHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "CopyObjectPart")
+
+	defer logger.AuditLog(w, r, "CopyObjectPart", mustGetClaimsFromToken(r))
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r.URL, guessIsBrowserReq(r))
+		return
+	}
+	if crypto.S3KMS.IsRequested(r.Header) && !api.AllowSSEKMS() {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrNotImplemented), r.URL, guessIsBrowserReq(r))
+		return
+	}
+	if !api.EncryptionEnabled() && crypto.IsRequested(r.Header) {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrNotImplemented), r.URL, guessIsBrowserReq(r))
+		return
+	}
+	vars := mux.Vars(r)
+	dstBucket := vars["bucket"]
+	dstObject, err := url.PathUnescape(vars["object"])
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if s3Error := checkRequestAuthType(ctx, r, policy.PutObjectAction, dstBucket, dstObject); s3Error != ErrNone {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Error), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	cpSrcPath, err := url.QueryUnescape(r.Header.Get(xhttp.AmzCopySource))
+	if err != nil {
+		cpSrcPath = r.Header.Get(xhttp.AmzCopySource)
+	}
+	if u, err := url.Parse(cpSrcPath); err == nil {
+		if vid := u.Query().Get("versionId"); vid != "" && vid != "null" {
+			writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrNoSuchVersion), r.URL, guessIsBrowserReq(r))
+			return
+		}
+		cpSrcPath = u.Path
+	}
+
+	srcBucket, srcObject := path2BucketObject(cpSrcPath)
+	if srcObject == "" || srcBucket == "" {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidCopySource), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if s3Error := checkRequestAuthType(ctx, r, policy.GetObjectAction, srcBucket, srcObject); s3Error != ErrNone {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Error), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	uploadID := r.URL.Query().Get("uploadId")
+	partIDString := r.URL.Query().Get("partNumber")
+
+	partID, err := strconv.Atoi(partIDString)
+	if err != nil {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidPart), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if isMaxPartID(partID) {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidMaxParts), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	srcOpts, err := copySrcOpts(ctx, r, srcBucket, srcObject)
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	dstOpts, err := copyDstOpts(ctx, r, dstBucket, dstObject, nil)
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	var rs *HTTPRangeSpec
+	if rangeHeader := r.Header.Get(xhttp.AmzCopySourceRange); rangeHeader != "" {
+		if rs, err = parseCopyPartRangeSpec(rangeHeader); err != nil {
+			writeCopyPartErr(ctx, w, err, r.URL, guessIsBrowserReq(r))
+			return
+		}
+	}
+
+	srcOpts.CheckCopyPrecondFn = func(o ObjectInfo, encETag string) bool {
+		return checkCopyObjectPartPreconditions(ctx, w, r, o, encETag)
+	}
+
+	getObjectInfo := objectAPI.GetObjectInfo
+
+	srcInfo, err := getObjectInfo(ctx, srcBucket, srcObject, srcOpts)
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	srcSize := srcInfo.Size
+	if objectAPI.IsEncryptionSupported() {
+		if s3Error, encrypted := DecryptCopyObjectInfo(&srcInfo, r.Header); s3Error != ErrNone {
+			writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Error), r.URL, guessIsBrowserReq(r))
+			return
+		} else if encrypted {
+			srcSize = srcInfo.Size
+		}
+	}
+	if srcInfo.IsCompressed() {
+		if srcSize = srcInfo.GetActualSize(); srcSize < 0 {
+			writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrInvalidDecompressedSize), r.URL, guessIsBrowserReq(r))
+			return
+		}
+	}
+
+	if err = checkCopyPartRangeWithSize(rs, srcSize); err != nil {
+		writeCopyPartErr(ctx, w, err, r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	startOffset, length, err := rs.GetOffsetLength(srcSize)
+	if err != nil {
+		writeCopyPartErr(ctx, w, err, r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if isMaxAllowedPartSize(length) {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrEntityTooLarge), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	gr, err := objectAPI.GetObjectNInfo(ctx, srcBucket, srcObject, rs, r.Header, readLock, srcOpts)
+	if err != nil {
+		if isErrPreconditionFailed(err) {
+			return
+		}
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+	defer gr.Close()
+	srcOpts.CheckCopyPrecondFn = nil
+
+	var li ListPartsInfo
+	if objectAPI.IsEncryptionSupported() || objectAPI.IsCompressionSupported() {
+		li, err = objectAPI.ListObjectParts(ctx, dstBucket, dstObject, uploadID, 0, 1, ObjectOptions{})
+		if err != nil {
+			writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+			return
+		}
+	}
+
+	rawReader, err := hash.NewReader(gr, length, "", "", length, globalCLIContext.StrictS3Compat)
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+	pReader := NewPutObjReader(rawReader, nil, nil)
+
+	if _, ok := li.UserDefined[ReservedMetadataPrefix+"compression"]; ok && objectAPI.IsCompressionSupported() {
+		s2c, _ := newS2CompressReader(rawReader)
+		defer s2c.Close()
+
+		compReader, err := hash.NewReader(s2c, -1, "", "", length, globalCLIContext.StrictS3Compat)
+		if err != nil {
+			writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+			return
+		}
+		pReader = NewPutObjReader(compReader, nil, nil)
+		pReader.rawReader = rawReader
+	}
+
+	var (
+		isEncrypted         bool
+		objectEncryptionKey crypto.ObjectKey
+	)
+	if objectAPI.IsEncryptionSupported() {
+		if crypto.IsEncrypted(li.UserDefined) {
+			if !crypto.SSEC.IsRequested(r.Header) && crypto.SSEC.IsEncrypted(li.UserDefined) {
+				writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrSSEMultipartEncrypted), r.URL, guessIsBrowserReq(r))
+				return
+			}
+			if crypto.SSEC.IsRequested(r.Header) && crypto.S3.IsEncrypted(li.UserDefined) {
+				writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrSSEMultipartEncrypted), r.URL, guessIsBrowserReq(r))
+				return
+			}
+			isEncrypted = true
+
+			objectEncryptionKey, err = getMultipartObjectKey(r.Header, dstBucket, dstObject, li.UserDefined)
+			if err != nil {
+				writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+				return
+			}
+
+			partKey := objectEncryptionKey.DerivePartKey(uint32(partID))
+			reader, err := sio.EncryptReader(rawReader, sio.Config{Key: partKey[:], MinVersion: sio.Version20})
+			if err != nil {
+				writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+				return
+			}
+
+			info := ObjectInfo{Size: length}
+			encReader, err := hash.NewReader(reader, info.EncryptedSize(), "", "", length, globalCLIContext.StrictS3Compat)
+			if err != nil {
+				writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+				return
+			}
+			pReader = NewPutObjReader(rawReader, encReader, &objectEncryptionKey)
+		}
+	}
+	srcInfo.PutObjReader = pReader
+
+	copyObjectPart := objectAPI.CopyObjectPart
+
+	partInfo, err := copyObjectPart(ctx, srcBucket, srcObject, dstBucket, dstObject, uploadID, partID,
+		startOffset, length, srcInfo, srcOpts, dstOpts)
+	if err != nil {
+		if isErrPreconditionFailed(err) {
+			return
+		}
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if isEncrypted {
+		partInfo.ETag = tryDecryptETag(objectEncryptionKey[:], partInfo.ETag, crypto.SSEC.IsRequested(r.Header))
+	}
+
+	response := generateCopyObjectPartResponse(partInfo.ETag, partInfo.LastModified)
+	encodedSuccessResponse := encodeResponse(response)
+
+	writeSuccessResponseXML(w, encodedSuccessResponse)
+}
+
+func (api objectAPIHandlers) GetObjectTaggingHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "GetObjectTagging")
+
+	defer logger.AuditLog(w, r, "GetObjectTagging", mustGetClaimsFromToken(r))
+
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object, err := url.PathUnescape(vars["object"])
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if s3Error := checkRequestAuthType(ctx, r, policy.GetObjectTaggingAction, bucket, object); s3Error != ErrNone {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Error), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	tags, err := objectAPI.GetObjectTag(ctx, bucket, object)
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	writeSuccessResponseXML(w, encodeResponse(tags))
+}
+
+func (api objectAPIHandlers) PutObjectTaggingHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "PutObjectTagging")
+
+	defer logger.AuditLog(w, r, "PutObjectTagging", mustGetClaimsFromToken(r))
+
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object, err := url.PathUnescape(vars["object"])
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if s3Error := checkRequestAuthType(ctx, r, policy.PutObjectTaggingAction, bucket, object); s3Error != ErrNone {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Error), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	tags, err := tagging.ParseTagging(io.LimitReader(r.Body, r.ContentLength))
+	if err != nil {
+		if _, ok := err.(tagging.Error); !ok {
+			writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrMalformedXML), r.URL, guessIsBrowserReq(r))
+			return
+		}
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if err = objectAPI.PutObjectTag(ctx, bucket, object, tags.String()); err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	writeSuccessResponseHeadersOnly(w)
+}
+
+func (api objectAPIHandlers) DeleteObjectTaggingHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "DeleteObjectTagging")
+
+	defer logger.AuditLog(w, r, "DeleteObjectTagging", mustGetClaimsFromToken(r))
+
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object, err := url.PathUnescape(vars["object"])
+	if err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(ErrServerNotInitialized), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if s3Error := checkRequestAuthType(ctx, r, policy.DeleteObjectTaggingAction, bucket, object); s3Error != ErrNone {
+		writeErrorResponse(ctx, w, errorCodes.ToAPIErr(s3Error), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	if err = objectAPI.DeleteObjectTag(ctx, bucket, object); err != nil {
+		writeErrorResponse(ctx, w, toAPIError(ctx, err), r.URL, guessIsBrowserReq(r))
+		return
+	}
+
+	writeSuccessNoContent(w)
+}
+
+func (api objectAPIHandlers) HeadObjectHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "HeadObject")
+
+	defer logger.AuditLog(w, r, "HeadObject", mustGetClaimsFromToken(r))
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponseHeadersOnly(w, errorCodes.ToAPIErr(ErrServerNotInitialized))
+		return
+	}
+	if crypto.S3.IsRequested(r.Header) || crypto.S3KMS.IsRequested(r.Header) {
+		writeErrorResponseHeadersOnly(w, errorCodes.ToAPIErr(ErrBadRequest))
+		return
+	}
+	if !api.EncryptionEnabled() && crypto.IsRequested(r.Header) {
+		writeErrorResponseHeadersOnly(w, errorCodes.ToAPIErr(ErrBadRequest))
+		return
+	}
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object, err := url.PathUnescape(vars["object"])
+	if err != nil {
+		writeErrorResponseHeadersOnly(w, toAPIError(ctx, err))
+		return
+	}
+
+	if vid := r.URL.Query().Get("versionId"); vid != "" && vid != "null" {
+		writeErrorResponseHeadersOnly(w, errorCodes.ToAPIErr(ErrNoSuchVersion))
+		return
+	}
+
+	getObjectInfo := objectAPI.GetObjectInfo
+
+	opts, err := getOpts(ctx, r, bucket, object)
+	if err != nil {
+		writeErrorResponseHeadersOnly(w, toAPIError(ctx, err))
+		return
+	}
+
+	if s3Error := checkRequestAuthType(ctx, r, policy.GetObjectAction, bucket, object); s3Error != ErrNone {
+		if getRequestAuthType(r) == authTypeAnonymous {
+			if globalPolicySys.IsAllowed(policy.Args{
+				Action:          policy.ListBucketAction,
+				BucketName:      bucket,
+				ConditionValues: getConditionValues(r, "", "", nil),
+				IsOwner:         false,
+			}) {
+				_, err = getObjectInfo(ctx, bucket, object, opts)
+				if toAPIError(ctx, err).Code == "NoSuchKey" {
+					s3Error = ErrNoSuchKey
+				}
+			}
+		}
+		writeErrorResponseHeadersOnly(w, errorCodes.ToAPIErr(s3Error))
+		return
+	}
+
+	objInfo, err := getObjectInfo(ctx, bucket, object, opts)
+	if err != nil {
+		writeErrorResponseHeadersOnly(w, toAPIError(ctx, err))
+		return
+	}
+
+	getRetPerms := checkRequestAuthType(ctx, r, policy.GetObjectRetentionAction, bucket, object)
+	legalHoldPerms := checkRequestAuthType(ctx, r, policy.GetObjectLegalHoldAction, bucket, object)
+
+	objInfo.UserDefined = objectlock.FilterObjectLockMetadata(objInfo.UserDefined, getRetPerms != ErrNone, legalHoldPerms != ErrNone)
+
+	if objectAPI.IsEncryptionSupported() {
+		objInfo.UserDefined = CleanIPOSInternalMetadataKeys(objInfo.UserDefined)
+		if _, err = DecryptObjectInfo(&objInfo, r.Header); err != nil {
+			writeErrorResponseHeadersOnly(w, toAPIError(ctx, err))
+			return
+		}
+	}
+
+	var rs *HTTPRangeSpec
+	rangeHeader := r.Header.Get("Range")
+	if rangeHeader != "" {
+		if rs, err = parseRequestRangeSpec(rangeHeader); err != nil {
+			if err == errInvalidRange {
+				writeErrorResponseHeadersOnly(w, errorCodes.ToAPIErr(ErrInvalidRange))
+				return
+			}
+
+			logger.LogIf(ctx, err, logger.Application)
+		}
+	}
+
+	if checkPreconditions(ctx, w, r, objInfo, opts) {
+		return
+	}
+
+	if objectAPI.IsEncryptionSupported() {
+		if crypto.IsEncrypted(objInfo.UserDefined) {
+			switch {
+			case crypto.S3.IsEncrypted(objInfo.UserDefined):
+				w.Header().Set(crypto.SSEHeader, crypto.SSEAlgorithmAES256)
+			case crypto.SSEC.IsEncrypted(objInfo.UserDefined):
+				w.Header().Set(crypto.SSECAlgorithm, r.Header.Get(crypto.SSECAlgorithm))
+				w.Header().Set(crypto.SSECKeyMD5, r.Header.Get(crypto.SSECKeyMD5))
+			}
+		}
+	}
+
+	if err = setObjectHeaders(w, objInfo, rs); err != nil {
+		writeErrorResponseHeadersOnly(w, toAPIError(ctx, err))
+		return
+	}
+
+	setHeadGetRespHeaders(w, r.URL.Query())
+
+	if rs != nil {
+		w.WriteHeader(http.StatusPartialContent)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -8,18 +8,32 @@ import (
 
 var globalHandlers = []HandlerFunc{
 	setBrowserRedirectHandler,
+
+	setSSETLSHandler,
+
+	setHTTPStatsHandler,
 }
 
 func configureServerHandler() (http.Handler, error) {
 	router := mux.NewRouter().SkipClean(true).UseEncodedPath()
 
+	if globalIsDistributed {
+		registerLockRESTHandlers(router, globalEndpoints.Peers())
+	}
+
+	registerMetricsRouter(router)
+
+	registerAdminRouter(router)
+
+	registerSTSRouter(router)
+
 	if globalBrowserEnabled {
 		if err := registerWebRouter(router); err != nil {
 			return nil, err
 		}
 	}
 
-	registerAPIRouter(router, true, false)
+	registerAPIRouter(router, true, GlobalKMS != nil)
 
 	router.NotFoundHandler = http.HandlerFunc(httpTraceAll(errorResponseHandler))
 	router.MethodNotAllowedHandler = http.HandlerFunc(httpTraceAll(errorResponseHandler))
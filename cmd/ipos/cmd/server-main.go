@@ -2,19 +2,28 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/storeros/ipos/cmd/ipos/config"
+	"github.com/storeros/ipos/cmd/ipos/config/api"
+	"github.com/storeros/ipos/cmd/ipos/config/compress"
+	"github.com/storeros/ipos/cmd/ipos/config/identity/openid"
+	"github.com/storeros/ipos/cmd/ipos/config/pinservice"
+	"github.com/storeros/ipos/cmd/ipos/crypto"
 	xhttp "github.com/storeros/ipos/cmd/ipos/http"
 	"github.com/storeros/ipos/cmd/ipos/logger"
-	"github.com/storeros/ipos/pkg/certs"
+	logFile "github.com/storeros/ipos/cmd/ipos/logger/target/file"
+	logHTTP "github.com/storeros/ipos/cmd/ipos/logger/target/http"
 	"github.com/storeros/ipos/pkg/cli"
 	"github.com/storeros/ipos/pkg/env"
+	"github.com/storeros/ipos/version"
 )
 
 var ServerFlags = []cli.Flag{
@@ -67,6 +76,24 @@ EXAMPLES:
      {{.Prompt}} {{.HelpName}} http://node{1...16}.example.com/mnt/export{1...32} \
             http://node{17...64}.example.com/mnt/export{1...64}
 
+  5. Start three ipos gateways sharing one IPFS node with distributed locking, run the following command on all the gateways
+     {{.Prompt}} {{.EnvVarSetCommand}} IPOS_ACCESS_KEY{{.AssignmentOperator}}ipos
+     {{.Prompt}} {{.EnvVarSetCommand}} IPOS_SECRET_KEY{{.AssignmentOperator}}iposstorage
+     {{.Prompt}} {{.HelpName}} http://ipfs.example.com:5001 http://gw1.example.com:9000 \
+            http://gw2.example.com:9000 http://gw3.example.com:9000
+
+  6. Start ipos gateway allowing at most 256 concurrent S3 requests and 10 writes per second per client IP
+     {{.Prompt}} {{.EnvVarSetCommand}} IPOS_API_REQUESTS_MAX{{.AssignmentOperator}}256
+     {{.Prompt}} {{.EnvVarSetCommand}} IPOS_API_WRITE_RATE_LIMIT_PER_IP{{.AssignmentOperator}}10,20
+     {{.Prompt}} {{.HelpName}} http://127.0.0.1:5001
+
+  7. Start ipos gateway sending audit logs to a webhook and to a local file rotated every 50MiB
+     {{.Prompt}} {{.EnvVarSetCommand}} IPOS_AUDIT_WEBHOOK_ENDPOINT{{.AssignmentOperator}}https://audit.example.com/ipos
+     {{.Prompt}} {{.EnvVarSetCommand}} IPOS_AUDIT_WEBHOOK_AUTH_TOKEN{{.AssignmentOperator}}"Bearer secret"
+     {{.Prompt}} {{.EnvVarSetCommand}} IPOS_AUDIT_FILE_PATH{{.AssignmentOperator}}/var/log/ipos/audit.log
+     {{.Prompt}} {{.EnvVarSetCommand}} IPOS_AUDIT_FILE_MAX_SIZE{{.AssignmentOperator}}50MiB
+     {{.Prompt}} {{.HelpName}} http://127.0.0.1:5001
+
 `,
 }
 
@@ -94,15 +121,184 @@ func serverHandleCmdArgs(ctx *cli.Context) {
 	}
 	logger.FatalIf(err, "Invalid command line arguments")
 
+	globalIsDistributed = len(globalEndpoints.Peers()) > 1
+
 	logger.FatalIf(checkPortAvailability(globalIPOSHost, globalIPOSPort), "Unable to start the server")
+
+	globalRootCAs, err = getRootCAs(globalCertsCADir.Get())
+	logger.FatalIf(err, "Unable to read root CAs")
+
+	globalPublicCerts, globalGetCertificate, globalIsSSL, err = getTLSConfig()
+	logger.FatalIf(err, "Unable to load the TLS configuration")
+
+	for _, publicCrt := range globalPublicCerts {
+		globalRootCAs.AddCert(publicCrt)
+	}
 }
 
 func serverHandleEnvVars() {
 	handleCommonEnvVars()
+
+	var err error
+	globalOpenIDConfig, err = openid.LookupConfig(NewGatewayHTTPTransport(), xhttp.DrainBody)
+	if err != nil {
+		logger.Fatal(err, "Unable to initialize OpenID")
+	}
+
+	kmsCfg, err := crypto.LookupConfig(NewGatewayHTTPTransport())
+	if err != nil {
+		logger.Fatal(err, "Unable to setup KMS config")
+	}
+
+	GlobalKMS, err = crypto.NewKMS(kmsCfg)
+	if err != nil {
+		logger.Fatal(err, "Unable to setup KMS with current KMS config")
+	}
+
+	globalAutoEncryption = kmsCfg.AutoEncryption
+
+	globalCompressConfig, err = compress.LookupConfig()
+	if err != nil {
+		logger.Fatal(config.ErrInvalidCompressionConfig(err), "Unable to setup compression")
+	}
+
+	apiCfg, err := api.LookupConfig()
+	if err != nil {
+		logger.Fatal(config.ErrInvalidAPIConfig(err), "Unable to setup API throttling")
+	}
+	globalAPIThrottling.init(apiCfg)
+
+	globalPinningService, err = pinservice.LookupConfig(NewGatewayHTTPTransport(), xhttp.DrainBody)
+	if err != nil {
+		logger.Fatal(config.ErrInvalidPinningServiceConfig(err), "Unable to setup pinning service")
+	}
+
+	if env.IsSet(config.EnvShutdownTimeout) {
+		globalShutdownTimeout, err = time.ParseDuration(env.Get(config.EnvShutdownTimeout, ""))
+		if err != nil || globalShutdownTimeout <= 0 {
+			logger.Fatal(config.ErrInvalidShutdownTimeout(err), "Invalid shutdown timeout")
+		}
+	}
+
+	auditCfg, err := logger.LookupConfig()
+	if err != nil {
+		logger.Fatal(config.ErrInvalidAuditConfig(err), "Unable to setup audit logging")
+	}
+
+	for _, webhook := range auditCfg.AuditWebhook {
+		logger.AddAuditTarget(logHTTP.New(
+			logHTTP.WithEndpoint(webhook.Endpoint),
+			logHTTP.WithAuthToken(webhook.AuthToken),
+			logHTTP.WithMaxRetry(webhook.MaxRetry),
+			logHTTP.WithUserAgent("IPOS/"+version.Version),
+			logHTTP.WithLogKind(string(logger.All)),
+			logHTTP.WithTransport(NewGatewayHTTPTransport()),
+			logHTTP.WithShutdownTimeout(globalShutdownTimeout),
+		))
+	}
+
+	if auditCfg.AuditFile.Enabled {
+		fileTarget, err := logFile.New(
+			logFile.WithPath(auditCfg.AuditFile.Path),
+			logFile.WithMaxSize(auditCfg.AuditFile.MaxSize),
+			logFile.WithMaxBackups(auditCfg.AuditFile.MaxBackups),
+			logFile.WithCompress(auditCfg.AuditFile.Compress),
+			logFile.WithLogKind(string(logger.All)),
+		)
+		if err != nil {
+			logger.Fatal(config.ErrInvalidAuditConfig(err), "Unable to open audit log file")
+		}
+		logger.AddAuditTarget(fileTarget)
+	}
+
+	globalPrometheusAuthType = prometheusAuthType(strings.ToLower(env.Get(config.EnvPrometheusAuthType, string(prometheusJWT))))
+	switch globalPrometheusAuthType {
+	case prometheusJWT, prometheusPublic:
+	default:
+		logger.Fatal(config.ErrInvalidPrometheusAuthType(nil), "Invalid prometheus auth type")
+	}
 }
 
 func newAllSubsystems() {
 	globalPolicySys = NewPolicySys()
+
+	globalLifecycleSys = NewLifecycleSys()
+
+	globalBucketSSEConfigSys = NewBucketSSEConfigSys()
+
+	globalBucketPinningSys = NewBucketPinningSys()
+
+	globalIAMSys = NewIAMSys()
+}
+
+func initAllSubsystems(newObject ObjectLayer) (err error) {
+	if err = globalIAMSys.Init(GlobalContext, newObject); err != nil {
+		return fmt.Errorf("Unable to initialize IAM system: %w", err)
+	}
+
+	buckets, err := newObject.ListBuckets(GlobalContext)
+	if err != nil {
+		return fmt.Errorf("Unable to list buckets: %w", err)
+	}
+
+	if err = globalPolicySys.Init(buckets, newObject); err != nil {
+		return fmt.Errorf("Unable to initialize policy system: %w", err)
+	}
+
+	if err = initBucketObjectLockConfig(buckets, newObject); err != nil {
+		return fmt.Errorf("Unable to initialize object lock system: %w", err)
+	}
+
+	if err = globalLifecycleSys.Init(buckets, newObject); err != nil {
+		return fmt.Errorf("Unable to initialize lifecycle system: %w", err)
+	}
+
+	if err = globalBucketSSEConfigSys.Init(buckets, newObject); err != nil {
+		return fmt.Errorf("Unable to initialize bucket encryption system: %w", err)
+	}
+
+	if err = globalBucketPinningSys.Init(buckets, newObject); err != nil {
+		return fmt.Errorf("Unable to initialize bucket pinning system: %w", err)
+	}
+
+	return nil
+}
+
+func reloadAllSubsystems(objAPI ObjectLayer) (err error) {
+	if objAPI == nil {
+		return errServerNotInitialized
+	}
+
+	if err = globalOpenIDConfig.PopulatePublicKey(); err != nil {
+		return fmt.Errorf("Unable to reload OpenID keys: %w", err)
+	}
+
+	if err = globalIAMSys.Load(GlobalContext); err != nil {
+		return fmt.Errorf("Unable to reload IAM system: %w", err)
+	}
+
+	buckets, err := objAPI.ListBuckets(GlobalContext)
+	if err != nil {
+		return fmt.Errorf("Unable to list buckets: %w", err)
+	}
+
+	if err = globalPolicySys.Init(buckets, objAPI); err != nil {
+		return fmt.Errorf("Unable to reload policy system: %w", err)
+	}
+
+	if err = globalLifecycleSys.Init(buckets, objAPI); err != nil {
+		return fmt.Errorf("Unable to reload lifecycle system: %w", err)
+	}
+
+	if err = globalBucketSSEConfigSys.Init(buckets, objAPI); err != nil {
+		return fmt.Errorf("Unable to reload bucket encryption system: %w", err)
+	}
+
+	if err = globalBucketPinningSys.Init(buckets, objAPI); err != nil {
+		return fmt.Errorf("Unable to reload bucket pinning system: %w", err)
+	}
+
+	return nil
 }
 
 func serverMain(ctx *cli.Context) {
@@ -110,7 +306,7 @@ func serverMain(ctx *cli.Context) {
 		cli.ShowCommandHelpAndExit(ctx, "server", 1)
 	}
 
-	signal.Notify(globalOSSignalCh, os.Interrupt, syscall.SIGTERM)
+	signal.Notify(globalOSSignalCh, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
 
 	serverHandleCmdArgs(ctx)
 
@@ -123,8 +319,12 @@ func serverMain(ctx *cli.Context) {
 		logger.Fatal(err, "Unable to configure one of server's RPC services")
 	}
 
-	var getCert certs.GetCertificateFunc
-	httpServer := xhttp.NewServer([]string{globalIPOSAddr}, criticalErrorHandler{handler}, getCert)
+	if globalIsDistributed {
+		globalLockers = newLockAPIs(globalEndpoints.Peers())
+	}
+
+	httpServer := xhttp.NewServer([]string{globalIPOSAddr}, criticalErrorHandler{handler}, globalGetCertificate)
+	httpServer.ShutdownTimeout = globalShutdownTimeout
 	httpServer.BaseContext = func(listener net.Listener) context.Context {
 		return GlobalContext
 	}
@@ -137,12 +337,23 @@ func serverMain(ctx *cli.Context) {
 	globalObjLayerMutex.Unlock()
 
 	newObject, err := newObjectLayer(globalEndpoints)
+	if err != nil {
+		logger.Fatal(err, "Unable to initialize backend")
+	}
+
+	newAllSubsystems()
 
 	globalObjLayerMutex.Lock()
 	globalObjectAPI = newObject
 	globalObjLayerMutex.Unlock()
 
-	newAllSubsystems()
+	if err = initAllSubsystems(newObject); err != nil {
+		logger.Fatal(err, "Unable to initialize sub-systems")
+	}
+
+	initDailyLifecycle(GlobalContext, newObject)
+
+	initDataUsageStats(GlobalContext, newObject)
 
 	printStartupMessage(getAPIEndpoints())
 
@@ -154,5 +365,5 @@ func newObjectLayer(endpoints Endpoints) (newObject ObjectLayer, err error) {
 	ep.Path = ""
 	ep.RawQuery = ""
 	ep.Fragment = ""
-	return NewIPFSObjectLayer(ep.String())
+	return NewIPFSObjectLayer(ep.String(), getLocalNodeID(endpoints))
 }
@@ -62,6 +62,10 @@ func printStartupSafeModeMessage(apiEndpoints []string, err error) {
 func printStartupMessage(apiEndpoints []string) {
 	strippedAPIEndpoints := stripStandardPorts(apiEndpoints)
 	printServerCommonMsg(strippedAPIEndpoints)
+
+	if globalIsSSL {
+		printCertificateMsg(globalPublicCerts)
+	}
 }
 
 func isNotIPv4(host string) bool {
@@ -1,8 +1,12 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+	"net/http"
 	"os"
 	"strings"
+	"syscall"
 
 	"github.com/storeros/ipos/cmd/ipos/logger"
 )
@@ -17,14 +21,53 @@ func handleSignals() {
 	}
 
 	stopProcess := func() bool {
-		return true
+		var err, oerr error
+
+		if httpServer := newHTTPServerFn(); httpServer != nil {
+			err = httpServer.Shutdown()
+			if errors.Is(err, http.ErrServerClosed) {
+				err = nil
+			}
+			logger.LogIf(context.Background(), err)
+		}
+
+		cancelGlobalContext()
+
+		if objAPI := newObjectLayerWithoutSafeModeFn(); objAPI != nil {
+			oerr = objAPI.Shutdown(context.Background())
+			logger.LogIf(context.Background(), oerr)
+		}
+
+		logger.CancelTargets()
+
+		return err == nil && oerr == nil
 	}
 
 	for {
 		select {
+		case err := <-globalHTTPServerErrorCh:
+			logger.LogIf(context.Background(), err)
+			exit(stopProcess())
 		case osSignal := <-globalOSSignalCh:
+			if osSignal == syscall.SIGHUP {
+				logger.Info("Reloading on signal: %s", strings.ToUpper(osSignal.String()))
+				logger.LogIf(context.Background(), reloadAllSubsystems(newObjectLayerWithoutSafeModeFn()))
+				continue
+			}
 			logger.Info("Exiting on signal: %s", strings.ToUpper(osSignal.String()))
 			exit(stopProcess())
+		case signal := <-globalServiceSignalCh:
+			switch signal {
+			case serviceRestart:
+				logger.Info("Restarting on service signal")
+				stop := stopProcess()
+				rerr := restartProcess()
+				logger.LogIf(context.Background(), rerr)
+				exit(stop && rerr == nil)
+			case serviceStop:
+				logger.Info("Stopping on service signal")
+				exit(stopProcess())
+			}
 		}
 	}
 }
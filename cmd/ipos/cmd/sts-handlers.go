@@ -1,11 +1,285 @@
 package cmd
 
-import ()
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/storeros/ipos/cmd/ipos/config/identity/openid"
+	xhttp "github.com/storeros/ipos/cmd/ipos/http"
+	"github.com/storeros/ipos/cmd/ipos/logger"
+	"github.com/storeros/ipos/pkg/auth"
+	iampolicy "github.com/storeros/ipos/pkg/iam/policy"
+	"github.com/storeros/ipos/pkg/wildcard"
+)
 
 const (
-	stsAPIVersion = "2011-06-15"
+	stsAPIVersion       = "2011-06-15"
+	stsVersion          = "Version"
+	stsAction           = "Action"
+	stsPolicy           = "Policy"
+	stsWebIdentityToken = "WebIdentityToken"
+	stsDurationSeconds  = "DurationSeconds"
 
 	webIdentity = "AssumeRoleWithWebIdentity"
+	assumeRole  = "AssumeRole"
 
 	stsRequestBodyLimit = 10 * (1 << 20)
+
+	maxSessionPolicySize = 2048
+
+	expClaim = "exp"
+	subClaim = "sub"
 )
+
+type stsAPIHandlers struct{}
+
+func registerSTSRouter(router *mux.Router) {
+	sts := &stsAPIHandlers{}
+
+	stsRouter := router.NewRoute().PathPrefix(SlashSeparator).Subrouter()
+
+	stsRouter.Methods(http.MethodPost).MatcherFunc(func(r *http.Request, rm *mux.RouteMatch) bool {
+		ctypeOk := wildcard.MatchSimple("application/x-www-form-urlencoded*", r.Header.Get(xhttp.ContentType))
+		authOk := wildcard.MatchSimple(signV4Algorithm+"*", r.Header.Get(xhttp.Authorization))
+		noQueries := len(r.URL.Query()) == 0
+		return ctypeOk && authOk && noQueries
+	}).HandlerFunc(httpTraceAll(sts.AssumeRole))
+
+	stsRouter.Methods(http.MethodPost).MatcherFunc(func(r *http.Request, rm *mux.RouteMatch) bool {
+		ctypeOk := wildcard.MatchSimple("application/x-www-form-urlencoded*", r.Header.Get(xhttp.ContentType))
+		noQueries := len(r.URL.Query()) == 0
+		return ctypeOk && noQueries
+	}).HandlerFunc(httpTraceAll(sts.AssumeRoleWithWebIdentity))
+
+	stsRouter.Methods(http.MethodPost).HandlerFunc(httpTraceAll(sts.AssumeRoleWithWebIdentity)).
+		Queries(stsAction, webIdentity).
+		Queries(stsVersion, stsAPIVersion).
+		Queries(stsWebIdentityToken, "{Token:.*}")
+}
+
+func checkAssumeRoleAuth(ctx context.Context, r *http.Request) (user auth.Credentials, s3Err APIErrorCode) {
+	switch getRequestAuthType(r) {
+	default:
+		return user, ErrAccessDenied
+	case authTypeSigned:
+		if s3Err = isReqAuthenticated(ctx, r, globalServerRegion, serviceSTS); s3Err != ErrNone {
+			return user, s3Err
+		}
+
+		var owner bool
+		user, owner, s3Err = getReqAccessKeyV4(r, globalServerRegion, serviceSTS)
+		if s3Err != ErrNone {
+			return user, s3Err
+		}
+
+		if owner {
+			return user, ErrAccessDenied
+		}
+	}
+
+	if getSessionToken(r) != "" {
+		return user, ErrAccessDenied
+	}
+
+	return user, ErrNone
+}
+
+func parseSessionPolicy(sessionPolicyStr string) error {
+	if len(sessionPolicyStr) > maxSessionPolicySize {
+		return fmt.Errorf("Session policy shouldn't exceed %d characters", maxSessionPolicySize)
+	}
+
+	if len(sessionPolicyStr) == 0 {
+		return nil
+	}
+
+	sessionPolicy, err := iampolicy.ParseConfig(bytes.NewReader([]byte(sessionPolicyStr)))
+	if err != nil {
+		return err
+	}
+
+	if sessionPolicy.Version == "" {
+		return fmt.Errorf("Version cannot be empty expecting '2012-10-17'")
+	}
+	return nil
+}
+
+func (sts *stsAPIHandlers) AssumeRole(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "AssumeRole")
+
+	user, s3Err := checkAssumeRoleAuth(ctx, r)
+	if s3Err != ErrNone {
+		writeSTSErrorResponse(ctx, w, false, STSErrorCode(s3Err), nil)
+		return
+	}
+
+	if globalIAMSys == nil {
+		writeSTSErrorResponse(ctx, w, true, ErrSTSNotInitialized, errServerNotInitialized)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, stsRequestBodyLimit)
+	if err := r.ParseForm(); err != nil {
+		writeSTSErrorResponse(ctx, w, true, ErrSTSInvalidParameterValue, err)
+		return
+	}
+
+	if r.Form.Get(stsVersion) != stsAPIVersion {
+		writeSTSErrorResponse(ctx, w, true, ErrSTSMissingParameter, fmt.Errorf("Invalid STS API version %s, expecting %s", r.Form.Get(stsVersion), stsAPIVersion))
+		return
+	}
+
+	action := r.Form.Get(stsAction)
+	if action != assumeRole {
+		writeSTSErrorResponse(ctx, w, true, ErrSTSInvalidParameterValue, fmt.Errorf("Unsupported action %s", action))
+		return
+	}
+
+	ctx = newContext(r, w, action)
+	defer logger.AuditLog(w, r, action, nil)
+
+	sessionPolicyStr := r.Form.Get(stsPolicy)
+	if err := parseSessionPolicy(sessionPolicyStr); err != nil {
+		writeSTSErrorResponse(ctx, w, true, ErrSTSMalformedPolicyDocument, err)
+		return
+	}
+
+	var err error
+	m := make(map[string]interface{})
+	m[expClaim], err = openid.GetDefaultExpiration(r.Form.Get(stsDurationSeconds))
+	if err != nil {
+		writeSTSErrorResponse(ctx, w, true, ErrSTSInvalidParameterValue, err)
+		return
+	}
+
+	policies, err := globalIAMSys.PolicyDBGet(user.AccessKey, false)
+	if err != nil {
+		writeSTSErrorResponse(ctx, w, true, ErrSTSInvalidParameterValue, err)
+		return
+	}
+
+	var policyName string
+	if len(policies) > 0 {
+		policyName = policies[0]
+	}
+
+	m[iamPolicyClaimNameOpenID()] = policyName
+	if len(sessionPolicyStr) > 0 {
+		m[iampolicy.SessionPolicyName] = sessionPolicyStr
+	}
+
+	cred, err := auth.GetNewCredentialsWithMetadata(m, globalActiveCred.SecretKey)
+	if err != nil {
+		writeSTSErrorResponse(ctx, w, true, ErrSTSInternalError, err)
+		return
+	}
+
+	cred.ParentUser = user.AccessKey
+
+	if err = globalIAMSys.SetTempUser(cred.AccessKey, cred, policyName); err != nil {
+		writeSTSErrorResponse(ctx, w, true, ErrSTSInternalError, err)
+		return
+	}
+
+	assumeRoleResponse := &AssumeRoleResponse{
+		Result: AssumeRoleResult{
+			Credentials: cred,
+		},
+	}
+
+	assumeRoleResponse.ResponseMetadata.RequestID = w.Header().Get(xhttp.AmzRequestID)
+	writeSuccessResponseXML(w, encodeResponse(assumeRoleResponse))
+}
+
+func (sts *stsAPIHandlers) AssumeRoleWithWebIdentity(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "AssumeRoleWithWebIdentity")
+
+	r.Body = http.MaxBytesReader(w, r.Body, stsRequestBodyLimit)
+	if err := r.ParseForm(); err != nil {
+		writeSTSErrorResponse(ctx, w, true, ErrSTSInvalidParameterValue, err)
+		return
+	}
+
+	if r.Form.Get(stsVersion) != stsAPIVersion {
+		writeSTSErrorResponse(ctx, w, true, ErrSTSMissingParameter, fmt.Errorf("Invalid STS API version %s, expecting %s", r.Form.Get(stsVersion), stsAPIVersion))
+		return
+	}
+
+	action := r.Form.Get(stsAction)
+	if action != webIdentity {
+		writeSTSErrorResponse(ctx, w, true, ErrSTSInvalidParameterValue, fmt.Errorf("Unsupported action %s", action))
+		return
+	}
+
+	defer logger.AuditLog(w, r, action, nil)
+
+	if globalIAMSys == nil || globalOpenIDConfig == nil || !globalOpenIDConfig.Enabled {
+		writeSTSErrorResponse(ctx, w, true, ErrSTSNotInitialized, errServerNotInitialized)
+		return
+	}
+
+	token := r.Form.Get(stsWebIdentityToken)
+	if token == "" {
+		writeSTSErrorResponse(ctx, w, true, ErrSTSMissingParameter, fmt.Errorf("%s is missing", stsWebIdentityToken))
+		return
+	}
+
+	m, err := globalOpenIDConfig.Validate(token, r.Form.Get(stsDurationSeconds))
+	if err != nil {
+		switch err {
+		case openid.ErrTokenExpired:
+			writeSTSErrorResponse(ctx, w, true, ErrSTSWebIdentityExpiredToken, err)
+		default:
+			writeSTSErrorResponse(ctx, w, true, ErrSTSInvalidParameterValue, err)
+		}
+		return
+	}
+
+	var policyName string
+	if policies, ok := (iampolicy.Args{Claims: m}).GetPolicies(iamPolicyClaimNameOpenID()); ok && len(policies) > 0 {
+		policyName = policies[0]
+	}
+	if policyName == "" {
+		writeSTSErrorResponse(ctx, w, true, ErrSTSInvalidParameterValue, fmt.Errorf("%s claim missing from the JWT token, credentials will not be generated", iamPolicyClaimNameOpenID()))
+		return
+	}
+	m[iamPolicyClaimNameOpenID()] = policyName
+
+	sessionPolicyStr := r.Form.Get(stsPolicy)
+	if err = parseSessionPolicy(sessionPolicyStr); err != nil {
+		writeSTSErrorResponse(ctx, w, true, ErrSTSMalformedPolicyDocument, err)
+		return
+	}
+	if len(sessionPolicyStr) > 0 {
+		m[iampolicy.SessionPolicyName] = sessionPolicyStr
+	}
+
+	cred, err := auth.GetNewCredentialsWithMetadata(m, globalActiveCred.SecretKey)
+	if err != nil {
+		writeSTSErrorResponse(ctx, w, true, ErrSTSInternalError, err)
+		return
+	}
+
+	var subFromToken string
+	if v, ok := m[subClaim]; ok {
+		subFromToken, _ = v.(string)
+	}
+
+	if err = globalIAMSys.SetTempUser(cred.AccessKey, cred, policyName); err != nil {
+		writeSTSErrorResponse(ctx, w, true, ErrSTSInvalidParameterValue, err)
+		return
+	}
+
+	webIdentityResponse := &AssumeRoleWithWebIdentityResponse{
+		Result: WebIdentityResult{
+			Credentials:                 cred,
+			SubjectFromWebIdentityToken: subFromToken,
+		},
+	}
+	webIdentityResponse.ResponseMetadata.RequestID = w.Header().Get(xhttp.AmzRequestID)
+	writeSuccessResponseXML(w, encodeResponse(webIdentityResponse))
+}
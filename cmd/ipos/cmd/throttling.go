@@ -1,33 +1,121 @@
 package cmd
 
 import (
+	"math"
 	"net/http"
 	"sync"
 	"time"
+
+	"github.com/storeros/ipos/cmd/ipos/config/api"
+	"github.com/storeros/ipos/pkg/handlers"
+)
+
+const rateLimiterSweepInterval = time.Minute
+
+const (
+	throttleRequestsMax     = "requests_max"
+	throttleReadAccessKey   = "read_access_key"
+	throttleWriteAccessKey  = "write_access_key"
+	throttleReadSourceIP    = "read_source_ip"
+	throttleWriteSourceIP   = "write_source_ip"
+	throttleRequestCanceled = "request_canceled"
 )
 
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type rateLimiter struct {
+	mu        sync.Mutex
+	limit     api.RateLimit
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+func (l *rateLimiter) allow(key string) bool {
+	if l == nil || key == "" {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := UTCNow()
+	if now.Sub(l.lastSweep) > rateLimiterSweepInterval {
+		l.sweep(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(l.limit.Burst), last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(l.limit.Burst), b.tokens+now.Sub(b.last).Seconds()*l.limit.Rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+func (l *rateLimiter) sweep(now time.Time) {
+	refill := time.Duration(float64(l.limit.Burst) / l.limit.Rate * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.last) > refill {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+func newRateLimiter(limit api.RateLimit) *rateLimiter {
+	if !limit.Enabled() {
+		return nil
+	}
+	return &rateLimiter{
+		limit:     limit,
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: UTCNow(),
+	}
+}
+
 type apiThrottling struct {
 	mu      sync.RWMutex
 	enabled bool
 
 	requestsDeadline time.Duration
 	requestsPool     chan struct{}
-}
 
-func (t *apiThrottling) init(max int, deadline time.Duration) {
-	if max <= 0 {
-		return
-	}
+	readPerAccessKey  *rateLimiter
+	writePerAccessKey *rateLimiter
+	readPerIP         *rateLimiter
+	writePerIP        *rateLimiter
+
+	throttled HTTPAPIStats
+}
 
+func (t *apiThrottling) init(cfg api.Config) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	t.requestsPool = make(chan struct{}, max)
-	t.requestsDeadline = deadline
+	t.readPerAccessKey = newRateLimiter(cfg.ReadPerAccessKey)
+	t.writePerAccessKey = newRateLimiter(cfg.WritePerAccessKey)
+	t.readPerIP = newRateLimiter(cfg.ReadPerIP)
+	t.writePerIP = newRateLimiter(cfg.WritePerIP)
+
+	if cfg.RequestsMax <= 0 {
+		return
+	}
+
+	t.requestsPool = make(chan struct{}, cfg.RequestsMax)
+	t.requestsDeadline = cfg.RequestsDeadline
 	t.enabled = true
 }
 
-func (t *apiThrottling) get() (chan struct{}, <-chan time.Time) {
+func (t *apiThrottling) get() (chan struct{}, *time.Timer) {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 
@@ -35,11 +123,59 @@ func (t *apiThrottling) get() (chan struct{}, <-chan time.Time) {
 		return nil, nil
 	}
 
-	return t.requestsPool, time.NewTimer(t.requestsDeadline).C
+	return t.requestsPool, time.NewTimer(t.requestsDeadline)
+}
+
+func (t *apiThrottling) allow(r *http.Request) (reason string, ok bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	perAccessKey, perIP := t.readPerAccessKey, t.readPerIP
+	accessKeyReason, sourceIPReason := throttleReadAccessKey, throttleReadSourceIP
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		perAccessKey, perIP = t.writePerAccessKey, t.writePerIP
+		accessKeyReason, sourceIPReason = throttleWriteAccessKey, throttleWriteSourceIP
+	}
+
+	if perIP != nil && !perIP.allow(handlers.GetSourceIP(r)) {
+		return sourceIPReason, false
+	}
+	// Only verified requests are charged to an access key, otherwise anyone
+	// could exhaust another user's limit by presenting their access key.
+	if perAccessKey != nil && !perAccessKey.allow(getThrottleAccessKey(r)) {
+		return accessKeyReason, false
+	}
+	return "", true
+}
+
+func getThrottleAccessKey(r *http.Request) string {
+	switch getRequestAuthType(r) {
+	case authTypeSigned, authTypePresigned, authTypeStreamingSigned:
+	default:
+		return ""
+	}
+
+	// The region is checked by the handler, only the signature matters here.
+	if reqSignatureV4Verify(r, "", serviceS3) != ErrNone {
+		return ""
+	}
+	cred, _, s3Err := getReqAccessKeyV4(r, "", serviceS3)
+	if s3Err != ErrNone {
+		return ""
+	}
+	return cred.AccessKey
 }
 
 func maxClients(f http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if reason, ok := globalAPIThrottling.allow(r); !ok {
+			globalAPIThrottling.throttled.Inc(reason)
+			writeErrorResponse(r.Context(), w,
+				errorCodes.ToAPIErr(ErrSlowDown),
+				r.URL, guessIsBrowserReq(r))
+			return
+		}
+
 		pool, deadlineTimer := globalAPIThrottling.get()
 		if pool == nil {
 			f.ServeHTTP(w, r)
@@ -48,14 +184,18 @@ func maxClients(f http.HandlerFunc) http.HandlerFunc {
 
 		select {
 		case pool <- struct{}{}:
+			deadlineTimer.Stop()
 			defer func() { <-pool }()
 			f.ServeHTTP(w, r)
-		case <-deadlineTimer:
+		case <-deadlineTimer.C:
+			globalAPIThrottling.throttled.Inc(throttleRequestsMax)
 			writeErrorResponse(r.Context(), w,
 				errorCodes.ToAPIErr(ErrOperationMaxedOut),
 				r.URL, guessIsBrowserReq(r))
 			return
 		case <-r.Context().Done():
+			deadlineTimer.Stop()
+			globalAPIThrottling.throttled.Inc(throttleRequestCanceled)
 			return
 		}
 	}
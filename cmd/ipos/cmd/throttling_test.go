@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/storeros/ipos/cmd/ipos/config/api"
+	xhttp "github.com/storeros/ipos/cmd/ipos/http"
+	"github.com/storeros/ipos/pkg/auth"
+)
+
+func signThrottleTestRequest(r *http.Request, accessKey, secretKey string) {
+	t := UTCNow()
+	r.Header.Set(xhttp.AmzDate, t.Format(iso8601Format))
+	r.Header.Set(xhttp.AmzContentSha256, unsignedPayload)
+
+	signedHeaders := []string{"host", strings.ToLower(xhttp.AmzContentSha256), strings.ToLower(xhttp.AmzDate)}
+	extractedSignedHeaders, _ := extractSignedHeaders(signedHeaders, r)
+	canonicalRequest := getCanonicalRequest(extractedSignedHeaders, unsignedPayload,
+		r.URL.Query().Encode(), r.URL.Path, r.Method)
+	scope := getScope(t, globalServerRegion)
+	signature := getSignature(getSigningKey(secretKey, t, globalServerRegion, serviceS3),
+		getStringToSign(canonicalRequest, t, scope))
+
+	r.Header.Set(xhttp.Authorization, signV4Algorithm+" Credential="+accessKey+SlashSeparator+scope+
+		", SignedHeaders="+strings.Join(signedHeaders, ";")+", Signature="+signature)
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	l := newRateLimiter(api.RateLimit{Rate: 1, Burst: 2})
+
+	testCases := []struct {
+		key      string
+		elapsed  time.Duration
+		expected bool
+	}{
+		{"a", 0, true},
+		{"a", 0, true},
+		{"a", 0, false},
+		// Buckets are independent per key.
+		{"b", 0, true},
+		// Tokens refill at the configured rate.
+		{"a", time.Second, true},
+		{"a", 0, false},
+		// Refills never exceed the burst.
+		{"b", time.Hour, true},
+		{"b", 0, true},
+		{"b", 0, false},
+		// Requests without a key are not limited.
+		{"", 0, true},
+		{"", 0, true},
+		{"", 0, true},
+	}
+
+	for i, testCase := range testCases {
+		if b, ok := l.buckets[testCase.key]; ok {
+			b.last = b.last.Add(-testCase.elapsed)
+		}
+		if allowed := l.allow(testCase.key); allowed != testCase.expected {
+			t.Errorf("Test %d: expected %v for key %q, got %v", i+1, testCase.expected, testCase.key, allowed)
+		}
+	}
+
+	if newRateLimiter(api.RateLimit{}) != nil {
+		t.Error("expected a disabled limit to have no limiter")
+	}
+	var disabled *rateLimiter
+	if !disabled.allow("a") {
+		t.Error("expected a disabled limiter to allow every request")
+	}
+}
+
+func TestRateLimiterSweep(t *testing.T) {
+	l := newRateLimiter(api.RateLimit{Rate: 1, Burst: 2})
+	l.allow("idle")
+	l.allow("active")
+
+	l.buckets["idle"].last = l.buckets["idle"].last.Add(-time.Minute)
+	l.lastSweep = l.lastSweep.Add(-2 * rateLimiterSweepInterval)
+	l.allow("active")
+
+	if _, ok := l.buckets["idle"]; ok {
+		t.Error("expected the idle bucket to be swept")
+	}
+	if _, ok := l.buckets["active"]; !ok {
+		t.Error("expected the active bucket to be kept")
+	}
+}
+
+func TestGetThrottleAccessKey(t *testing.T) {
+	defer func(cred auth.Credentials) { globalActiveCred = cred }(globalActiveCred)
+	globalActiveCred = auth.Credentials{AccessKey: "ipos-access-key", SecretKey: "ipos-secret-key"}
+
+	testCases := []struct {
+		accessKey string
+		secretKey string
+		expected  string
+	}{
+		// Anonymous requests are not charged to any access key.
+		{"", "", ""},
+		// Correctly signed requests are charged to their access key.
+		{"ipos-access-key", "ipos-secret-key", "ipos-access-key"},
+		// Forged signatures are not charged to the presented access key.
+		{"ipos-access-key", "wrong-secret-key", ""},
+		// Unknown access keys are not charged either.
+		{"unknown-access-key", "ipos-secret-key", ""},
+	}
+
+	for i, testCase := range testCases {
+		r := httptest.NewRequest(http.MethodGet, "http://localhost:9000/bucket/object", nil)
+		if testCase.accessKey != "" {
+			signThrottleTestRequest(r, testCase.accessKey, testCase.secretKey)
+		}
+		if accessKey := getThrottleAccessKey(r); accessKey != testCase.expected {
+			t.Errorf("Test %d: expected access key %q, got %q", i+1, testCase.expected, accessKey)
+		}
+	}
+}
+
+func TestAPIThrottlingAllow(t *testing.T) {
+	defer func(cred auth.Credentials) { globalActiveCred = cred }(globalActiveCred)
+	globalActiveCred = auth.Credentials{AccessKey: "ipos-access-key", SecretKey: "ipos-secret-key"}
+
+	var throttling apiThrottling
+	throttling.init(api.Config{
+		ReadPerAccessKey: api.RateLimit{Rate: 0.001, Burst: 1},
+		ReadPerIP:        api.RateLimit{Rate: 0.001, Burst: 2},
+	})
+
+	testCases := []struct {
+		sourceIP  string
+		secretKey string
+		reason    string
+		ok        bool
+	}{
+		// Forged requests never consume the access key's limit...
+		{"10.0.0.1", "wrong-secret-key", "", true},
+		{"10.0.0.2", "wrong-secret-key", "", true},
+		// ...so the owner can still use it.
+		{"10.0.0.3", "ipos-secret-key", "", true},
+		{"10.0.0.4", "ipos-secret-key", throttleReadAccessKey, false},
+		// Forged requests are limited by their source IP instead.
+		{"10.0.0.1", "wrong-secret-key", "", true},
+		{"10.0.0.1", "wrong-secret-key", throttleReadSourceIP, false},
+	}
+
+	for i, testCase := range testCases {
+		r := httptest.NewRequest(http.MethodGet, "http://localhost:9000/bucket/object", nil)
+		r.RemoteAddr = testCase.sourceIP + ":1234"
+		signThrottleTestRequest(r, "ipos-access-key", testCase.secretKey)
+		if reason, ok := throttling.allow(r); reason != testCase.reason || ok != testCase.ok {
+			t.Errorf("Test %d: expected (%q, %v), got (%q, %v)", i+1, testCase.reason, testCase.ok, reason, ok)
+		}
+	}
+}
@@ -28,6 +28,8 @@ var errInvalidBucketName = errors.New("The specified bucket is not valid")
 
 var errInvalidRange = errors.New("Invalid range")
 
+var errMultipleRanges = errors.New("Multiple byte ranges are not supported")
+
 var errInvalidRangeSource = errors.New("Range specified exceeds source object size")
 
 var errNotFirstDisk = errors.New("Not first disk")
@@ -51,3 +53,9 @@ var errNoSuchPolicy = errors.New("Specified canned policy does not exist")
 var errIAMActionNotAllowed = errors.New("Specified IAM action is not allowed under the current configuration")
 
 var errAccessDenied = errors.New("Do not have enough permissions to access this resource")
+
+var errUnexpected = errors.New("Unexpected error, please report this issue at https://github.com/storeros/ipos/issues")
+
+var errPinningServiceNotConfigured = errors.New("Remote pinning requested but no pinning service endpoint is configured")
+
+var errRemotePinQueueFull = errors.New("Remote pinning queue is full, dropping request")
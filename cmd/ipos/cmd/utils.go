@@ -27,6 +27,7 @@ import (
 	xhttp "github.com/storeros/ipos/cmd/ipos/http"
 	"github.com/storeros/ipos/cmd/ipos/logger"
 	"github.com/storeros/ipos/pkg/handlers"
+	iampolicy "github.com/storeros/ipos/pkg/iam/policy"
 	"github.com/storeros/ipos/pkg/madmin"
 
 	humanize "github.com/dustin/go-humanize"
@@ -531,7 +532,10 @@ func lcp(l []string) string {
 }
 
 func iamPolicyClaimNameOpenID() string {
-	return "claim_prefix" + "claim_name"
+	if globalOpenIDConfig == nil {
+		return iampolicy.PolicyName
+	}
+	return globalOpenIDConfig.ClaimPrefix + globalOpenIDConfig.ClaimName
 }
 
 func iamPolicyClaimNameSA() string {
@@ -183,6 +183,11 @@ func (web *webAPIHandlers) DeleteBucket(r *http.Request, args *RemoveBucketArgs,
 		return toJSONError(ctx, err, args.BucketName)
 	}
 
+	globalPolicySys.Remove(args.BucketName)
+	globalLifecycleSys.Remove(args.BucketName)
+	globalBucketSSEConfigSys.Remove(args.BucketName)
+	globalBucketPinningSys.Remove(args.BucketName)
+
 	return nil
 }
 
@@ -803,24 +808,51 @@ func (web *webAPIHandlers) Upload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if objectAPI.IsEncryptionSupported() && !HasSuffix(object, SlashSeparator) {
+		applyDefaultEncryption(r.Header, bucket)
+	}
+
 	var pReader *PutObjReader
 	var reader io.Reader = r.Body
+	var actualReader *hash.Reader
+	var indexFn func() []byte
 	actualSize := size
 
+	if objectAPI.IsCompressionSupported() && isCompressible(r.Header, object) && size > 0 {
+		metadata[ReservedMetadataPrefix+"compression"] = compressionAlgorithmV2
+		metadata[ReservedMetadataPrefix+"actual-size"] = strconv.FormatInt(size, 10)
+
+		actualReader, err = hash.NewReader(reader, size, "", "", actualSize, globalCLIContext.StrictS3Compat)
+		if err != nil {
+			writeWebErrorResponse(w, err)
+			return
+		}
+
+		var s2c io.ReadCloser
+		s2c, indexFn = newS2CompressReader(actualReader)
+		defer s2c.Close()
+		reader = s2c
+		size = -1
+	}
+
 	hashReader, err := hash.NewReader(reader, size, "", "", actualSize, globalCLIContext.StrictS3Compat)
 	if err != nil {
 		writeWebErrorResponse(w, err)
 		return
 	}
 	pReader = NewPutObjReader(hashReader, nil, nil)
+	if actualReader != nil {
+		pReader.rawReader = actualReader
+	}
 	var opts ObjectOptions
 	opts, err = putOpts(ctx, r, bucket, object, metadata)
 	if err != nil {
 		writeErrorResponseHeadersOnly(w, toAPIError(ctx, err))
 		return
 	}
-	if objectAPI.IsEncryptionSupported() {
-		if crypto.IsRequested(r.Header) && !HasSuffix(object, SlashSeparator) {
+	opts.CompressionIndexFn = indexFn
+	if objectAPI.IsEncryptionSupported() && !HasSuffix(object, SlashSeparator) {
+		if crypto.IsRequested(r.Header) {
 			rawReader := hashReader
 			var objectEncryptionKey crypto.ObjectKey
 			reader, objectEncryptionKey, err = EncryptRequest(hashReader, r, bucket, object, metadata)
@@ -1513,6 +1545,10 @@ func presignedGet(host, bucket, object string, expiry int64, creds auth.Credenti
 	queryStr := s3utils.QueryEncode(query)
 
 	path := SlashSeparator + path.Join(bucket, object)
+	if vhost, ok := getVirtualHost(host, bucket); ok {
+		host = vhost
+		path = strings.TrimPrefix(path, SlashSeparator+bucket)
+	}
 
 	extractedSignedHeaders := make(http.Header)
 	extractedSignedHeaders.Set("host", host)
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/storeros/ipos/cmd/ipos/config"
+	"github.com/storeros/ipos/pkg/env"
+)
+
+const (
+	EnvAPIRequestsMax      = "IPOS_API_REQUESTS_MAX"
+	EnvAPIRequestsDeadline = "IPOS_API_REQUESTS_DEADLINE"
+
+	EnvAPIReadRateLimitPerAccessKey  = "IPOS_API_READ_RATE_LIMIT_PER_ACCESS_KEY"
+	EnvAPIWriteRateLimitPerAccessKey = "IPOS_API_WRITE_RATE_LIMIT_PER_ACCESS_KEY"
+	EnvAPIReadRateLimitPerIP         = "IPOS_API_READ_RATE_LIMIT_PER_IP"
+	EnvAPIWriteRateLimitPerIP        = "IPOS_API_WRITE_RATE_LIMIT_PER_IP"
+)
+
+const DefaultRequestsDeadline = 10 * time.Second
+
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+func (l RateLimit) Enabled() bool {
+	return l.Rate > 0
+}
+
+type Config struct {
+	RequestsMax      int
+	RequestsDeadline time.Duration
+
+	ReadPerAccessKey  RateLimit
+	WritePerAccessKey RateLimit
+	ReadPerIP         RateLimit
+	WritePerIP        RateLimit
+}
+
+func LookupConfig() (cfg Config, err error) {
+	if v := env.Get(EnvAPIRequestsMax, ""); v != "" {
+		if cfg.RequestsMax, err = strconv.Atoi(v); err != nil || cfg.RequestsMax < 0 {
+			return cfg, fmt.Errorf("invalid value for %s: %s", EnvAPIRequestsMax, v)
+		}
+	}
+
+	cfg.RequestsDeadline = DefaultRequestsDeadline
+	if v := env.Get(EnvAPIRequestsDeadline, ""); v != "" {
+		if cfg.RequestsDeadline, err = time.ParseDuration(v); err != nil || cfg.RequestsDeadline <= 0 {
+			return cfg, fmt.Errorf("invalid value for %s: %s", EnvAPIRequestsDeadline, v)
+		}
+	}
+
+	limits := []struct {
+		envName string
+		limit   *RateLimit
+	}{
+		{EnvAPIReadRateLimitPerAccessKey, &cfg.ReadPerAccessKey},
+		{EnvAPIWriteRateLimitPerAccessKey, &cfg.WritePerAccessKey},
+		{EnvAPIReadRateLimitPerIP, &cfg.ReadPerIP},
+		{EnvAPIWriteRateLimitPerIP, &cfg.WritePerIP},
+	}
+	for _, l := range limits {
+		if *l.limit, err = parseRateLimit(env.Get(l.envName, "")); err != nil {
+			return cfg, fmt.Errorf("invalid value for %s: %w", l.envName, err)
+		}
+	}
+
+	return cfg, nil
+}
+
+func parseRateLimit(s string) (l RateLimit, err error) {
+	if s == "" {
+		return l, nil
+	}
+
+	fields := strings.Split(s, config.ValueSeparator)
+	if len(fields) > 2 {
+		return l, fmt.Errorf("expected <rate>[,<burst>], got %s", s)
+	}
+
+	if l.Rate, err = strconv.ParseFloat(strings.TrimSpace(fields[0]), 64); err != nil {
+		return l, err
+	}
+	if l.Rate < 0 || math.IsInf(l.Rate, 0) || math.IsNaN(l.Rate) {
+		return l, fmt.Errorf("rate must be a positive number, got %s", fields[0])
+	}
+
+	l.Burst = int(math.Ceil(l.Rate))
+	if len(fields) == 2 {
+		if l.Burst, err = strconv.Atoi(strings.TrimSpace(fields[1])); err != nil {
+			return l, err
+		}
+		if l.Burst <= 0 {
+			return l, fmt.Errorf("burst must be a positive integer, got %s", fields[1])
+		}
+	}
+	return l, nil
+}
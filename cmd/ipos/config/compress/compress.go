@@ -0,0 +1,73 @@
+package compress
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/storeros/ipos/cmd/ipos/config"
+	"github.com/storeros/ipos/pkg/env"
+)
+
+const (
+	EnvCompress                  = "IPOS_COMPRESS"
+	EnvCompressExtensions        = "IPOS_COMPRESS_EXTENSIONS"
+	EnvCompressMimeTypes         = "IPOS_COMPRESS_MIME_TYPES"
+	EnvCompressExcludeExtensions = "IPOS_COMPRESS_EXCLUDE_EXTENSIONS"
+	EnvCompressExcludeMimeTypes  = "IPOS_COMPRESS_EXCLUDE_MIME_TYPES"
+)
+
+type Config struct {
+	Enabled bool
+
+	Extensions []string
+	MimeTypes  []string
+
+	ExcludeExtensions []string
+	ExcludeMimeTypes  []string
+}
+
+func LookupConfig() (cfg Config, err error) {
+	if compress := env.Get(EnvCompress, ""); compress != "" {
+		if cfg.Enabled, err = parseBool(compress); err != nil {
+			return cfg, fmt.Errorf("invalid value for %s: %w", EnvCompress, err)
+		}
+	}
+
+	cfg.Extensions = parseExtensions(env.Get(EnvCompressExtensions, ""))
+	cfg.MimeTypes = parseList(env.Get(EnvCompressMimeTypes, ""))
+	cfg.ExcludeExtensions = parseExtensions(env.Get(EnvCompressExcludeExtensions, ""))
+	cfg.ExcludeMimeTypes = parseList(env.Get(EnvCompressExcludeMimeTypes, ""))
+
+	return cfg, nil
+}
+
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "on":
+		return true, nil
+	case "off":
+		return false, nil
+	}
+	return strconv.ParseBool(s)
+}
+
+func parseList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, config.ValueSeparator) {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func parseExtensions(s string) []string {
+	extensions := parseList(s)
+	for i, ext := range extensions {
+		if !strings.HasPrefix(ext, ".") {
+			extensions[i] = "." + ext
+		}
+	}
+	return extensions
+}
@@ -8,4 +8,9 @@ const (
 	EnvAccessKey = "IPOS_ACCESS_KEY"
 	EnvSecretKey = "IPOS_SECRET_KEY"
 	EnvEndpoints = "IPOS_ENDPOINTS"
+	EnvDomain    = "IPOS_DOMAIN"
+
+	EnvShutdownTimeout = "IPOS_SHUTDOWN_TIMEOUT"
+
+	EnvPrometheusAuthType = "IPOS_PROMETHEUS_AUTH_TYPE"
 )
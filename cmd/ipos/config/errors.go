@@ -19,6 +19,48 @@ var (
 		"",
 	)
 
+	ErrInvalidDomainValue = newErrFn(
+		"Invalid domain value",
+		"Please check the passed value",
+		"Domain can only accept DNS compatible values",
+	)
+
+	ErrInvalidShutdownTimeout = newErrFn(
+		"Invalid shutdown timeout",
+		"Please provide a positive duration such as '30s' or '2m'",
+		"Set IPOS_SHUTDOWN_TIMEOUT to the time in-flight requests may take to drain",
+	)
+
+	ErrInvalidCompressionConfig = newErrFn(
+		"Invalid compression configuration",
+		"Please check the passed value",
+		"IPOS_COMPRESS accepts 'on' or 'off', extension and MIME type lists are comma separated",
+	)
+
+	ErrInvalidAPIConfig = newErrFn(
+		"Invalid API throttling configuration",
+		"Please check the passed value",
+		"IPOS_API_REQUESTS_MAX must be a non-negative integer, IPOS_API_REQUESTS_DEADLINE a duration and rate limits '<requests per second>[,<burst>]'",
+	)
+
+	ErrInvalidPinningServiceConfig = newErrFn(
+		"Invalid pinning service configuration",
+		"Please check the passed value",
+		"IPOS_PINNING_SERVICE_ENDPOINT must be an http(s) URL of an IPFS Pinning Service API endpoint",
+	)
+
+	ErrInvalidAuditConfig = newErrFn(
+		"Invalid audit log configuration",
+		"Please check the passed value",
+		"IPOS_AUDIT_WEBHOOK_ENDPOINT must be an http(s) URL and IPOS_AUDIT_FILE_MAX_SIZE a size such as '100MiB'",
+	)
+
+	ErrInvalidPrometheusAuthType = newErrFn(
+		"Invalid prometheus auth type",
+		"Please check the passed value",
+		"IPOS_PROMETHEUS_AUTH_TYPE accepts 'jwt' or 'public'",
+	)
+
 	ErrUnexpectedDataContent = newErrFn(
 		"Unexpected data content",
 		"Please contact IPOS at https://ipos.storeros.com",
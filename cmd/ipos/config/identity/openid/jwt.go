@@ -0,0 +1,272 @@
+package openid
+
+import (
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+
+	"github.com/storeros/ipos/pkg/auth"
+	"github.com/storeros/ipos/pkg/env"
+	iampolicy "github.com/storeros/ipos/pkg/iam/policy"
+)
+
+const (
+	EnvIdentityOpenIDJWKSURL     = "IPOS_IDENTITY_OPENID_JWKS_URL"
+	EnvIdentityOpenIDClientID    = "IPOS_IDENTITY_OPENID_CLIENT_ID"
+	EnvIdentityOpenIDClaimName   = "IPOS_IDENTITY_OPENID_CLAIM_NAME"
+	EnvIdentityOpenIDClaimPrefix = "IPOS_IDENTITY_OPENID_CLAIM_PREFIX"
+)
+
+const jwksRefreshInterval = time.Minute
+
+var (
+	ErrTokenExpired = errors.New("token expired")
+	ErrInvalidAud   = errors.New("invalid audience")
+)
+
+type Config struct {
+	Enabled     bool
+	JWKSURL     *url.URL
+	ClientID    string
+	ClaimName   string
+	ClaimPrefix string
+
+	mu          sync.RWMutex
+	publicKeys  map[string]crypto.PublicKey
+	refreshMu   sync.Mutex
+	lastRefresh time.Time
+	transport   *http.Transport
+	closeRespFn func(io.ReadCloser)
+}
+
+func LookupConfig(transport *http.Transport, closeRespFn func(io.ReadCloser)) (*Config, error) {
+	c := &Config{
+		ClientID:    env.Get(EnvIdentityOpenIDClientID, ""),
+		ClaimName:   env.Get(EnvIdentityOpenIDClaimName, iampolicy.PolicyName),
+		ClaimPrefix: env.Get(EnvIdentityOpenIDClaimPrefix, ""),
+		publicKeys:  make(map[string]crypto.PublicKey),
+		transport:   transport,
+		closeRespFn: closeRespFn,
+	}
+
+	jwksURL := env.Get(EnvIdentityOpenIDJWKSURL, "")
+	if jwksURL == "" {
+		return c, nil
+	}
+
+	u, err := url.Parse(jwksURL)
+	if err != nil {
+		return c, err
+	}
+	c.JWKSURL = u
+	c.Enabled = true
+
+	if err = c.PopulatePublicKey(); err != nil {
+		return c, err
+	}
+	return c, nil
+}
+
+func (c *Config) readJWKS() (io.ReadCloser, error) {
+	switch c.JWKSURL.Scheme {
+	case "", "file":
+		return os.Open(c.JWKSURL.Path)
+	case "http", "https":
+	default:
+		return nil, fmt.Errorf("Unsupported JWKS URL scheme %s", c.JWKSURL.Scheme)
+	}
+
+	transport := http.DefaultTransport
+	if c.transport != nil {
+		transport = c.transport
+	}
+	client := &http.Client{
+		Transport: transport,
+	}
+
+	resp, err := client.Get(c.JWKSURL.String())
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		c.closeRespFn(resp.Body)
+		return nil, errors.New(resp.Status)
+	}
+	return resp.Body, nil
+}
+
+func (c *Config) PopulatePublicKey() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	body, err := c.readJWKS()
+	if err != nil {
+		return err
+	}
+	defer c.closeRespFn(body)
+
+	var jwk JWKS
+	if err = json.NewDecoder(body).Decode(&jwk); err != nil {
+		return err
+	}
+
+	publicKeys := make(map[string]crypto.PublicKey, len(jwk.Keys))
+	for _, key := range jwk.Keys {
+		publicKeys[key.Kid], err = key.DecodePublicKey()
+		if err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	c.publicKeys = publicKeys
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *Config) publicKey(kid string) (crypto.PublicKey, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	key, ok := c.publicKeys[kid]
+	return key, ok
+}
+
+func (c *Config) refreshPublicKey(kid string) (crypto.PublicKey, bool) {
+	// Unknown kids may come from a key rotation at the IdP, but tokens are
+	// presented without authentication, so limit how often we refetch.
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	if key, ok := c.publicKey(kid); ok {
+		return key, true
+	}
+	if time.Since(c.lastRefresh) < jwksRefreshInterval {
+		return nil, false
+	}
+	c.lastRefresh = time.Now()
+	if err := c.PopulatePublicKey(); err != nil {
+		return nil, false
+	}
+	return c.publicKey(kid)
+}
+
+func GetDefaultExpiration(dsecs string) (time.Duration, error) {
+	defaultExpiryDuration := time.Duration(60) * time.Minute
+	if dsecs != "" {
+		expirySecs, err := strconv.ParseInt(dsecs, 10, 64)
+		if err != nil {
+			return 0, auth.ErrInvalidDuration
+		}
+
+		if expirySecs < 900 || expirySecs > 43200 {
+			return 0, auth.ErrInvalidDuration
+		}
+
+		defaultExpiryDuration = time.Duration(expirySecs) * time.Second
+	}
+	return defaultExpiryDuration, nil
+}
+
+func updateClaimsExpiry(dsecs string, claims map[string]interface{}) error {
+	expStr, ok := claims["exp"]
+	if !ok || expStr == "" {
+		return ErrTokenExpired
+	}
+
+	if dsecs == "" {
+		return nil
+	}
+
+	expAt, err := auth.ExpToInt64(expStr)
+	if err != nil {
+		return err
+	}
+
+	defaultExpiryDuration, err := GetDefaultExpiration(dsecs)
+	if err != nil {
+		return err
+	}
+
+	if time.Unix(expAt, 0).UTC().Sub(time.Now().UTC()) < defaultExpiryDuration {
+		defaultExpiryDuration = time.Unix(expAt, 0).UTC().Sub(time.Now().UTC())
+	}
+
+	expiry := time.Now().UTC().Add(defaultExpiryDuration).Unix()
+	claims["exp"] = strconv.FormatInt(expiry, 10)
+	return nil
+}
+
+func (c *Config) checkAudience(claims jwtgo.MapClaims) error {
+	if c.ClientID == "" {
+		return nil
+	}
+
+	switch aud := claims["aud"].(type) {
+	case string:
+		if aud == c.ClientID {
+			return nil
+		}
+	case []interface{}:
+		for _, v := range aud {
+			if s, ok := v.(string); ok && s == c.ClientID {
+				return nil
+			}
+		}
+	}
+	return ErrInvalidAud
+}
+
+func (c *Config) Validate(token, dsecs string) (map[string]interface{}, error) {
+	jp := new(jwtgo.Parser)
+	jp.ValidMethods = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}
+
+	keyFuncCallback := func(jwtToken *jwtgo.Token) (interface{}, error) {
+		kid, ok := jwtToken.Header["kid"].(string)
+		if !ok {
+			return nil, fmt.Errorf("Invalid kid value %v", jwtToken.Header["kid"])
+		}
+		key, ok := c.publicKey(kid)
+		if !ok {
+			key, ok = c.refreshPublicKey(kid)
+		}
+		if !ok {
+			return nil, fmt.Errorf("No public key found for kid %s", kid)
+		}
+		return key, nil
+	}
+
+	var claims jwtgo.MapClaims
+	jwtToken, err := jp.ParseWithClaims(token, &claims, keyFuncCallback)
+	if err != nil {
+		if verr, ok := err.(*jwtgo.ValidationError); ok && verr.Errors&jwtgo.ValidationErrorExpired != 0 {
+			return nil, ErrTokenExpired
+		}
+		return nil, err
+	}
+
+	if !jwtToken.Valid {
+		return nil, ErrTokenExpired
+	}
+
+	if err = c.checkAudience(claims); err != nil {
+		return nil, err
+	}
+
+	if err = updateClaimsExpiry(dsecs, claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
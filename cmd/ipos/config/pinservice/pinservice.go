@@ -0,0 +1,168 @@
+package pinservice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/storeros/ipos/pkg/env"
+)
+
+const (
+	EnvPinningServiceEndpoint = "IPOS_PINNING_SERVICE_ENDPOINT"
+	EnvPinningServiceToken    = "IPOS_PINNING_SERVICE_TOKEN"
+)
+
+const requestTimeout = 30 * time.Second
+
+type Config struct {
+	Enabled  bool
+	Endpoint *url.URL
+	Token    string
+
+	client      *http.Client
+	closeRespFn func(io.ReadCloser)
+}
+
+type PinStatus struct {
+	RequestID string    `json:"requestid"`
+	Status    string    `json:"status"`
+	Created   time.Time `json:"created"`
+}
+
+type Error struct {
+	StatusCode int
+	Reason     string
+	Details    string
+}
+
+func (e Error) Error() string {
+	if e.Details != "" {
+		return fmt.Sprintf("pinning service: %s (%d): %s", e.Reason, e.StatusCode, e.Details)
+	}
+	return fmt.Sprintf("pinning service: %s (%d)", e.Reason, e.StatusCode)
+}
+
+func LookupConfig(transport *http.Transport, closeRespFn func(io.ReadCloser)) (*Config, error) {
+	c := &Config{
+		Token:       env.Get(EnvPinningServiceToken, ""),
+		closeRespFn: closeRespFn,
+	}
+
+	endpoint := env.Get(EnvPinningServiceEndpoint, "")
+	if endpoint == "" {
+		return c, nil
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return c, err
+	}
+	switch u.Scheme {
+	case "http", "https":
+	default:
+		return c, fmt.Errorf("Unsupported pinning service endpoint scheme %s", u.Scheme)
+	}
+
+	var rt http.RoundTripper = http.DefaultTransport
+	if transport != nil {
+		rt = transport
+	}
+
+	c.Endpoint = u
+	c.Enabled = true
+	c.client = &http.Client{
+		Transport: rt,
+		Timeout:   requestTimeout,
+	}
+	return c, nil
+}
+
+func (c *Config) Pin(ctx context.Context, cid, name string) (status PinStatus, err error) {
+	body, err := json.Marshal(struct {
+		CID  string `json:"cid"`
+		Name string `json:"name,omitempty"`
+	}{cid, name})
+	if err != nil {
+		return status, err
+	}
+
+	err = c.do(ctx, http.MethodPost, "pins", body, &status)
+	return status, err
+}
+
+func (c *Config) GetPin(ctx context.Context, requestID string) (status PinStatus, err error) {
+	err = c.do(ctx, http.MethodGet, "pins/"+url.PathEscape(requestID), nil, &status)
+	return status, err
+}
+
+func (c *Config) Unpin(ctx context.Context, requestID string) error {
+	err := c.do(ctx, http.MethodDelete, "pins/"+url.PathEscape(requestID), nil, nil)
+	if e, ok := err.(Error); ok && e.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+func (c *Config) do(ctx context.Context, method, relPath string, body []byte, v interface{}) error {
+	if !c.Enabled {
+		return fmt.Errorf("pinning service is not configured, set %s", EnvPinningServiceEndpoint)
+	}
+
+	u := *c.Endpoint
+	u.Path = singleJoiningSlash(u.Path, relPath)
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer c.closeRespFn(resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var errResp struct {
+			Error struct {
+				Reason  string `json:"reason"`
+				Details string `json:"details"`
+			} `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&errResp)
+		e := Error{
+			StatusCode: resp.StatusCode,
+			Reason:     errResp.Error.Reason,
+			Details:    errResp.Error.Details,
+		}
+		if e.Reason == "" {
+			e.Reason = http.StatusText(resp.StatusCode)
+		}
+		return e
+	}
+
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func singleJoiningSlash(a, b string) string {
+	if len(a) > 0 && a[len(a)-1] == '/' {
+		return a + b
+	}
+	return a + "/" + b
+}
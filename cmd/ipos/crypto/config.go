@@ -0,0 +1,72 @@
+package crypto
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/storeros/ipos/pkg/env"
+)
+
+const (
+	EnvKMSMasterKey      = "IPOS_KMS_MASTER_KEY"
+	EnvKMSAutoEncryption = "IPOS_KMS_AUTO_ENCRYPTION"
+
+	EnvKMSKesEndpoint = "IPOS_KMS_KES_ENDPOINT"
+	EnvKMSKesKeyFile  = "IPOS_KMS_KES_KEY_FILE"
+	EnvKMSKesCertFile = "IPOS_KMS_KES_CERT_FILE"
+	EnvKMSKesCAPath   = "IPOS_KMS_KES_CA_PATH"
+	EnvKMSKesKeyName  = "IPOS_KMS_KES_KEY_NAME"
+)
+
+type KMSConfig struct {
+	AutoEncryption bool
+
+	MasterKey string
+
+	Kes KesConfig
+}
+
+func LookupConfig(transport *http.Transport) (cfg KMSConfig, err error) {
+	cfg.MasterKey = env.Get(EnvKMSMasterKey, "")
+
+	if endpoint := env.Get(EnvKMSKesEndpoint, ""); endpoint != "" {
+		cfg.Kes = KesConfig{
+			Enabled:      true,
+			Endpoint:     endpoint,
+			KeyFile:      env.Get(EnvKMSKesKeyFile, ""),
+			CertFile:     env.Get(EnvKMSKesCertFile, ""),
+			CAPath:       env.Get(EnvKMSKesCAPath, ""),
+			DefaultKeyID: env.Get(EnvKMSKesKeyName, ""),
+			Transport:    transport,
+		}
+		if err = cfg.Kes.Verify(); err != nil {
+			return cfg, err
+		}
+	}
+
+	if cfg.MasterKey != "" && cfg.Kes.Enabled {
+		return cfg, Errorf("crypto: both %s and %s are set", EnvKMSMasterKey, EnvKMSKesEndpoint)
+	}
+
+	if autoEncryption := env.Get(EnvKMSAutoEncryption, ""); autoEncryption != "" {
+		if cfg.AutoEncryption, err = strconv.ParseBool(autoEncryption); err != nil {
+			return cfg, Errorf("crypto: invalid value for %s: %v", EnvKMSAutoEncryption, err)
+		}
+	}
+
+	if cfg.AutoEncryption && cfg.MasterKey == "" && !cfg.Kes.Enabled {
+		return cfg, Errorf("crypto: %s requires a KMS to be configured", EnvKMSAutoEncryption)
+	}
+
+	return cfg, nil
+}
+
+func NewKMS(cfg KMSConfig) (KMS, error) {
+	switch {
+	case cfg.MasterKey != "":
+		return ParseMasterKey(cfg.MasterKey)
+	case cfg.Kes.Enabled:
+		return NewKes(cfg.Kes)
+	}
+	return nil, nil
+}
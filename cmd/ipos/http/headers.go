@@ -79,4 +79,10 @@ const (
 	IPOSServerStatus = "x-ipos-server-status"
 
 	IPOSForceDelete = "x-ipos-force-delete"
+
+	IPOSPinStatus = "x-ipos-pin-status"
+
+	IPOSCID = "x-ipos-cid"
+
+	IPOSTime = "x-ipos-time"
 )
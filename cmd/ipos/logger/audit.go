@@ -122,3 +122,20 @@ func AuditLog(w http.ResponseWriter, r *http.Request, api string, reqClaims map[
 		_ = t.Send(entry, string(All))
 	}
 }
+
+func AuditEvent(api, bucket, object string, err error) {
+	statusCode := http.StatusOK
+	if err != nil {
+		statusCode = http.StatusInternalServerError
+	}
+
+	for _, t := range AuditTargets {
+		entry := audit.NewEntry(globalDeploymentID)
+		entry.API.Name = api
+		entry.API.Bucket = bucket
+		entry.API.Object = object
+		entry.API.Status = http.StatusText(statusCode)
+		entry.API.StatusCode = statusCode
+		_ = t.Send(entry, string(All))
+	}
+}
@@ -0,0 +1,121 @@
+package logger
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/storeros/ipos/pkg/env"
+)
+
+const (
+	EnvAuditWebhookEndpoint  = "IPOS_AUDIT_WEBHOOK_ENDPOINT"
+	EnvAuditWebhookAuthToken = "IPOS_AUDIT_WEBHOOK_AUTH_TOKEN"
+	EnvAuditWebhookMaxRetry  = "IPOS_AUDIT_WEBHOOK_MAX_RETRY"
+
+	EnvAuditFilePath       = "IPOS_AUDIT_FILE_PATH"
+	EnvAuditFileMaxSize    = "IPOS_AUDIT_FILE_MAX_SIZE"
+	EnvAuditFileMaxBackups = "IPOS_AUDIT_FILE_MAX_BACKUPS"
+	EnvAuditFileCompress   = "IPOS_AUDIT_FILE_COMPRESS"
+)
+
+const (
+	defaultAuditWebhookMaxRetry = 3
+	defaultAuditFileMaxSize     = 100 * humanize.MiByte
+)
+
+type HTTP struct {
+	Endpoint  string
+	AuthToken string
+	MaxRetry  int
+}
+
+type File struct {
+	Enabled    bool
+	Path       string
+	MaxSize    int64
+	MaxBackups int
+	Compress   bool
+}
+
+type Config struct {
+	AuditWebhook map[string]HTTP
+	AuditFile    File
+}
+
+func LookupConfig() (cfg Config, err error) {
+	cfg.AuditWebhook = make(map[string]HTTP)
+	for _, endpointEnv := range env.List(EnvAuditWebhookEndpoint) {
+		suffix := strings.TrimPrefix(endpointEnv, EnvAuditWebhookEndpoint)
+		if suffix != "" && !strings.HasPrefix(suffix, "_") {
+			continue
+		}
+		target := strings.TrimPrefix(suffix, "_")
+
+		endpoint := env.Get(endpointEnv, "")
+		if endpoint == "" {
+			continue
+		}
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid value for %s: %w", endpointEnv, err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return cfg, fmt.Errorf("invalid value for %s: unsupported scheme %q", endpointEnv, u.Scheme)
+		}
+
+		webhook := HTTP{
+			Endpoint:  endpoint,
+			AuthToken: env.Get(EnvAuditWebhookAuthToken+suffix, ""),
+			MaxRetry:  defaultAuditWebhookMaxRetry,
+		}
+		if v := env.Get(EnvAuditWebhookMaxRetry+suffix, ""); v != "" {
+			if webhook.MaxRetry, err = strconv.Atoi(v); err != nil || webhook.MaxRetry < 0 {
+				return cfg, fmt.Errorf("invalid value for %s: %s", EnvAuditWebhookMaxRetry+suffix, v)
+			}
+		}
+		cfg.AuditWebhook[strings.ToLower(target)] = webhook
+	}
+
+	cfg.AuditFile = File{
+		Path:     env.Get(EnvAuditFilePath, ""),
+		MaxSize:  defaultAuditFileMaxSize,
+		Compress: true,
+	}
+	if cfg.AuditFile.Path == "" {
+		return cfg, nil
+	}
+	cfg.AuditFile.Enabled = true
+
+	if v := env.Get(EnvAuditFileMaxSize, ""); v != "" {
+		size, err := humanize.ParseBytes(v)
+		if err != nil || size == 0 {
+			return cfg, fmt.Errorf("invalid value for %s: %s", EnvAuditFileMaxSize, v)
+		}
+		cfg.AuditFile.MaxSize = int64(size)
+	}
+
+	if v := env.Get(EnvAuditFileMaxBackups, ""); v != "" {
+		if cfg.AuditFile.MaxBackups, err = strconv.Atoi(v); err != nil || cfg.AuditFile.MaxBackups < 0 {
+			return cfg, fmt.Errorf("invalid value for %s: %s", EnvAuditFileMaxBackups, v)
+		}
+	}
+
+	if v := env.Get(EnvAuditFileCompress, ""); v != "" {
+		switch strings.ToLower(v) {
+		case "on":
+			cfg.AuditFile.Compress = true
+		case "off":
+			cfg.AuditFile.Compress = false
+		default:
+			if cfg.AuditFile.Compress, err = strconv.ParseBool(v); err != nil {
+				return cfg, fmt.Errorf("invalid value for %s: %s", EnvAuditFileCompress, v)
+			}
+		}
+	}
+
+	return cfg, nil
+}
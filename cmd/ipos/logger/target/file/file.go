@@ -0,0 +1,323 @@
+package file
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/storeros/ipos/cmd/ipos/logger"
+)
+
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+type Target struct {
+	logCh      chan interface{}
+	compressCh chan string
+	doneCh     chan struct{}
+	wg         sync.WaitGroup
+	once       sync.Once
+
+	path       string
+	maxSize    int64
+	maxBackups int
+	compress   bool
+	logKind    string
+
+	file *os.File
+	size int64
+}
+
+func (f *Target) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	fi, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = fi.Size()
+	return nil
+}
+
+func (f *Target) write(entry interface{}) error {
+	logJSON, err := json.Marshal(&entry)
+	if err != nil {
+		return err
+	}
+	logJSON = append(logJSON, '\n')
+
+	if f.file == nil {
+		if err = f.open(); err != nil {
+			return err
+		}
+	}
+
+	var rotateErr error
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(logJSON)) > f.maxSize {
+		// A failed rotation still leaves the original file open when
+		// possible, keep the entry there instead of losing it.
+		if rotateErr = f.rotate(); f.file == nil {
+			return rotateErr
+		}
+	}
+
+	n, err := f.file.Write(logJSON)
+	f.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return err
+}
+
+func (f *Target) backupName() string {
+	ext := filepath.Ext(f.path)
+	prefix := strings.TrimSuffix(f.path, ext)
+	return prefix + "-" + time.Now().UTC().Format(backupTimeFormat) + ext
+}
+
+func (f *Target) rotate() error {
+	if err := f.file.Sync(); err != nil {
+		return err
+	}
+	if err := f.file.Close(); err != nil {
+		return f.reopenAfter(err)
+	}
+
+	backup := f.backupName()
+	if err := os.Rename(f.path, backup); err != nil {
+		return f.reopenAfter(err)
+	}
+	if err := f.open(); err != nil {
+		return f.reopenAfter(err)
+	}
+
+	if f.compress {
+		f.compressCh <- backup
+		return nil
+	}
+	return f.removeOldBackups()
+}
+
+// reopenAfter keeps logging to the original path after a failed rotation. If
+// even that fails, the next write tries to open the file again.
+func (f *Target) reopenAfter(err error) error {
+	f.file = nil
+	if oerr := f.open(); oerr != nil {
+		return fmt.Errorf("%w, unable to reopen %s: %v", err, f.path, oerr)
+	}
+	return err
+}
+
+func compressFile(name string) (err error) {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			dst.Close()
+			os.Remove(name + ".gz")
+		}
+	}()
+
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err != nil {
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		return err
+	}
+	if err = dst.Sync(); err != nil {
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(name)
+}
+
+func (f *Target) removeOldBackups() error {
+	if f.maxBackups <= 0 {
+		return nil
+	}
+
+	ext := filepath.Ext(f.path)
+	prefix := strings.TrimSuffix(filepath.Base(f.path), ext) + "-"
+
+	entries, err := ioutil.ReadDir(filepath.Dir(f.path))
+	if err != nil {
+		return err
+	}
+
+	type backup struct {
+		name string
+		time time.Time
+	}
+
+	var backups []backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		ts := strings.TrimPrefix(name, prefix)
+		if strings.HasSuffix(ts, ext+".gz") {
+			ts = strings.TrimSuffix(ts, ext+".gz")
+		} else if strings.HasSuffix(ts, ext) {
+			ts = strings.TrimSuffix(ts, ext)
+		} else {
+			continue
+		}
+		t, perr := time.Parse(backupTimeFormat, ts)
+		if perr != nil {
+			continue
+		}
+		backups = append(backups, backup{name, t})
+	}
+	if len(backups) <= f.maxBackups {
+		return nil
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].time.Before(backups[j].time)
+	})
+	for _, b := range backups[:len(backups)-f.maxBackups] {
+		if err = os.Remove(filepath.Join(filepath.Dir(f.path), b.name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *Target) startFileLogger() {
+	f.wg.Add(2)
+	go func() {
+		defer f.wg.Done()
+		for backup := range f.compressCh {
+			logger.LogOnceIf(context.Background(), compressFile(backup), f.path)
+			logger.LogOnceIf(context.Background(), f.removeOldBackups(), f.path)
+		}
+	}()
+
+	go func() {
+		defer f.wg.Done()
+		defer close(f.compressCh)
+		for {
+			select {
+			case entry := <-f.logCh:
+				logger.LogOnceIf(context.Background(), f.write(entry), f.path)
+			case <-f.doneCh:
+				for {
+					select {
+					case entry := <-f.logCh:
+						logger.LogOnceIf(context.Background(), f.write(entry), f.path)
+					default:
+						if f.file != nil {
+							logger.LogIf(context.Background(), f.file.Sync())
+							logger.LogIf(context.Background(), f.file.Close())
+						}
+						return
+					}
+				}
+			}
+		}
+	}()
+}
+
+type Option func(*Target)
+
+func WithPath(path string) Option {
+	return func(t *Target) {
+		t.path = path
+	}
+}
+
+func WithMaxSize(maxSize int64) Option {
+	return func(t *Target) {
+		t.maxSize = maxSize
+	}
+}
+
+func WithMaxBackups(maxBackups int) Option {
+	return func(t *Target) {
+		t.maxBackups = maxBackups
+	}
+}
+
+func WithCompress(compress bool) Option {
+	return func(t *Target) {
+		t.compress = compress
+	}
+}
+
+func WithLogKind(logKind string) Option {
+	return func(t *Target) {
+		t.logKind = strings.ToUpper(logKind)
+	}
+}
+
+func New(opts ...Option) (*Target, error) {
+	f := &Target{
+		logCh:      make(chan interface{}, 10000),
+		compressCh: make(chan string, 16),
+		doneCh:     make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	f.startFileLogger()
+	return f, nil
+}
+
+func (f *Target) Send(entry interface{}, errKind string) error {
+	if f.logKind != errKind && f.logKind != "ALL" {
+		return nil
+	}
+
+	// Audit records must not be dropped, block until there is room in
+	// the buffer instead.
+	select {
+	case <-f.doneCh:
+		return errors.New("log target is closed")
+	case f.logCh <- entry:
+	}
+
+	return nil
+}
+
+func (f *Target) Cancel() {
+	f.once.Do(func() {
+		close(f.doneCh)
+	})
+	f.wg.Wait()
+}
@@ -2,51 +2,191 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	xhttp "github.com/storeros/ipos/cmd/ipos/http"
+	"github.com/storeros/ipos/cmd/ipos/logger"
+)
+
+const (
+	initialRetryInterval = 250 * time.Millisecond
+	maxRetryInterval     = 5 * time.Second
+	requestTimeout       = 30 * time.Second
+	droppedLogInterval   = time.Minute
 )
 
 type Target struct {
-	logCh chan interface{}
+	// dropped is accessed atomically and must stay 64-bit aligned.
+	dropped uint64
+
+	logCh   chan interface{}
+	retryCh chan []byte
+	doneCh  chan struct{}
+	wg      sync.WaitGroup
+
+	// closeMu orders Send against Cancel so that nothing is queued
+	// after the final drain.
+	closeMu sync.RWMutex
+	closed  bool
+
+	// ctx bounds every post, it is cancelled once the shutdown
+	// timeout expires.
+	ctx             context.Context
+	cancel          context.CancelFunc
+	shutdownTimeout time.Duration
 
 	endpoint  string
 	authToken string
 	userAgent string
 	logKind   string
+	maxRetry  int
 	client    http.Client
 }
 
-func (h *Target) startHTTPLogger() {
-	go func() {
-		for entry := range h.logCh {
-			logJSON, err := json.Marshal(&entry)
-			if err != nil {
-				continue
-			}
+func (h *Target) send(entry interface{}) error {
+	logJSON, err := json.Marshal(&entry)
+	if err != nil {
+		h.drop()
+		return err
+	}
+
+	if err = h.post(logJSON); err == nil || h.maxRetry <= 0 {
+		if err != nil {
+			h.drop()
+		}
+		return err
+	}
 
-			req, err := http.NewRequest(http.MethodPost, h.endpoint, bytes.NewReader(logJSON))
+	// Retry in the background so that a slow or failing endpoint does not
+	// hold up the entries queued behind this one.
+	select {
+	case h.retryCh <- logJSON:
+	default:
+		h.drop()
+	}
+	return err
+}
+
+func (h *Target) retry(logJSON []byte) error {
+	var err error
+	var retryInterval = initialRetryInterval
+	for i := 0; i < h.maxRetry; i++ {
+		select {
+		case <-time.After(retryInterval):
+		case <-h.doneCh:
+			// Shutting down, make one last attempt unless the shutdown
+			// timeout has already expired.
+			if err = h.ctx.Err(); err == nil {
+				err = h.post(logJSON)
+			}
 			if err != nil {
-				continue
+				h.drop()
 			}
-			req.Header.Set(xhttp.ContentType, "application/json")
+			return err
+		}
+		if err = h.post(logJSON); err == nil {
+			return nil
+		}
+		if retryInterval *= 2; retryInterval > maxRetryInterval {
+			retryInterval = maxRetryInterval
+		}
+	}
+	h.drop()
+	return err
+}
 
-			req.Header.Set("User-Agent", h.userAgent)
+func (h *Target) drop() {
+	atomic.AddUint64(&h.dropped, 1)
+}
 
-			if h.authToken != "" {
-				req.Header.Set("Authorization", h.authToken)
-			}
+func (h *Target) logDropped(reported uint64) uint64 {
+	dropped := atomic.LoadUint64(&h.dropped)
+	if dropped != reported {
+		logger.LogIf(context.Background(), fmt.Errorf("%s: %d log entries dropped since startup", h.endpoint, dropped))
+	}
+	return dropped
+}
 
-			resp, err := h.client.Do(req)
-			if err != nil {
-				h.client.CloseIdleConnections()
-				continue
+func (h *Target) post(logJSON []byte) error {
+	req, err := http.NewRequestWithContext(h.ctx, http.MethodPost, h.endpoint, bytes.NewReader(logJSON))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(xhttp.ContentType, "application/json")
+
+	req.Header.Set("User-Agent", h.userAgent)
+
+	if h.authToken != "" {
+		req.Header.Set("Authorization", h.authToken)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		h.client.CloseIdleConnections()
+		return fmt.Errorf("%s returned '%w', please check your endpoint configuration", h.endpoint, err)
+	}
+
+	defer xhttp.DrainBody(resp.Body)
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%s returned '%s', please check your endpoint configuration", h.endpoint, resp.Status)
+	}
+	return nil
+}
+
+func (h *Target) startHTTPLogger() {
+	h.wg.Add(2)
+	go func() {
+		defer h.wg.Done()
+		var reported uint64
+		ticker := time.NewTicker(droppedLogInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case logJSON, ok := <-h.retryCh:
+				if !ok {
+					h.logDropped(reported)
+					return
+				}
+				logger.LogOnceIf(context.Background(), h.retry(logJSON), h.endpoint)
+			case <-ticker.C:
+				reported = h.logDropped(reported)
 			}
+		}
+	}()
 
-			xhttp.DrainBody(resp.Body)
+	go func() {
+		defer h.wg.Done()
+		defer close(h.retryCh)
+		for {
+			select {
+			case entry := <-h.logCh:
+				logger.LogOnceIf(context.Background(), h.send(entry), h.endpoint)
+			case <-h.doneCh:
+				// Flush whatever is still buffered before giving up,
+				// entries left once the shutdown timeout expires are
+				// dropped.
+				for {
+					select {
+					case entry := <-h.logCh:
+						if h.ctx.Err() != nil {
+							h.drop()
+							continue
+						}
+						logger.LogOnceIf(context.Background(), h.send(entry), h.endpoint)
+					default:
+						return
+					}
+				}
+			}
 		}
 	}()
 }
@@ -77,22 +217,40 @@ func WithAuthToken(authToken string) Option {
 	}
 }
 
+func WithMaxRetry(maxRetry int) Option {
+	return func(t *Target) {
+		t.maxRetry = maxRetry
+	}
+}
+
+func WithShutdownTimeout(shutdownTimeout time.Duration) Option {
+	return func(t *Target) {
+		t.shutdownTimeout = shutdownTimeout
+	}
+}
+
 func WithTransport(transport *http.Transport) Option {
 	return func(t *Target) {
 		t.client = http.Client{
 			Transport: transport,
+			Timeout:   requestTimeout,
 		}
 	}
 }
 
 func New(opts ...Option) *Target {
 	h := &Target{
-		logCh: make(chan interface{}, 10000),
+		logCh:   make(chan interface{}, 10000),
+		retryCh: make(chan []byte, 1000),
+		doneCh:  make(chan struct{}),
+
+		shutdownTimeout: xhttp.DefaultShutdownTimeout,
 	}
 
 	for _, opt := range opts {
 		opt(h)
 	}
+	h.ctx, h.cancel = context.WithCancel(context.Background())
 
 	h.startHTTPLogger()
 	return h
@@ -103,11 +261,31 @@ func (h *Target) Send(entry interface{}, errKind string) error {
 		return nil
 	}
 
+	h.closeMu.RLock()
+	defer h.closeMu.RUnlock()
+	if h.closed {
+		return errors.New("log target is closed")
+	}
+
 	select {
 	case h.logCh <- entry:
 	default:
+		h.drop()
 		return errors.New("log buffer full")
 	}
 
 	return nil
 }
+
+func (h *Target) Cancel() {
+	h.closeMu.Lock()
+	if !h.closed {
+		h.closed = true
+		close(h.doneCh)
+	}
+	h.closeMu.Unlock()
+
+	timer := time.AfterFunc(h.shutdownTimeout, h.cancel)
+	defer timer.Stop()
+	h.wg.Wait()
+}
@@ -1,7 +1,10 @@
 package logger
 
+import "sync"
+
 type Target interface {
 	Send(entry interface{}, errKind string) error
+	Cancel()
 }
 
 var Targets = []Target{}
@@ -9,3 +12,17 @@ var Targets = []Target{}
 func AddTarget(t Target) {
 	Targets = append(Targets, t)
 }
+
+func CancelTargets() {
+	// Targets flush concurrently so that their shutdown timeouts do not
+	// add up.
+	var wg sync.WaitGroup
+	for _, t := range append(append([]Target{}, Targets...), AuditTargets...) {
+		wg.Add(1)
+		go func(t Target) {
+			defer wg.Done()
+			t.Cancel()
+		}(t)
+	}
+	wg.Wait()
+}
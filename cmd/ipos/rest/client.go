@@ -0,0 +1,152 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	xhttp "github.com/storeros/ipos/cmd/ipos/http"
+	xnet "github.com/storeros/ipos/pkg/net"
+)
+
+const DefaultRESTTimeout = 1 * time.Minute
+
+const (
+	offline = iota
+	online
+	closed
+)
+
+type NetworkError struct {
+	Err error
+}
+
+func (n *NetworkError) Error() string {
+	return n.Err.Error()
+}
+
+func (n *NetworkError) Unwrap() error {
+	return n.Err
+}
+
+type Client struct {
+	connected int32
+
+	MaxErrResponseSize int64
+
+	HealthCheckFn func() bool
+
+	HealthCheckInterval time.Duration
+
+	HealthCheckTimeout time.Duration
+
+	httpClient   *http.Client
+	url          *url.URL
+	newAuthToken func(audience string) string
+}
+
+func (c *Client) CallWithContext(ctx context.Context, method string, values url.Values, body io.Reader, length int64) (reply io.ReadCloser, err error) {
+	if !c.IsOnline() {
+		return nil, &NetworkError{Err: errors.New("remote server offline")}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url.String()+method+"?"+values.Encode(), body)
+	if err != nil {
+		return nil, &NetworkError{Err: err}
+	}
+	req.Header.Set("Authorization", "Bearer "+c.newAuthToken(req.URL.Query().Encode()))
+	req.Header.Set(xhttp.IPOSTime, time.Now().UTC().Format(time.RFC3339))
+	if length > 0 {
+		req.ContentLength = length
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if xnet.IsNetworkOrHostDown(err) {
+			c.MarkOffline()
+		}
+		return nil, &NetworkError{Err: err}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer xhttp.DrainBody(resp.Body)
+
+		b, err := ioutil.ReadAll(io.LimitReader(resp.Body, c.MaxErrResponseSize))
+		if err != nil {
+			return nil, err
+		}
+		if len(b) > 0 {
+			return nil, errors.New(string(b))
+		}
+		return nil, errors.New(resp.Status)
+	}
+
+	return resp.Body, nil
+}
+
+func (c *Client) Call(method string, values url.Values, body io.Reader, length int64) (reply io.ReadCloser, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultRESTTimeout)
+	respBody, err := c.CallWithContext(ctx, method, values, body, length)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &cancelReadCloser{ReadCloser: respBody, cancel: cancel}, nil
+}
+
+func (c *Client) Close() {
+	atomic.StoreInt32(&c.connected, closed)
+	c.httpClient.CloseIdleConnections()
+}
+
+func (c *Client) IsOnline() bool {
+	return atomic.LoadInt32(&c.connected) == online
+}
+
+func (c *Client) MarkOffline() {
+	if !atomic.CompareAndSwapInt32(&c.connected, online, offline) || c.HealthCheckFn == nil {
+		return
+	}
+
+	go func() {
+		r := rand.New(rand.NewSource(time.Now().UnixNano()))
+		for {
+			if atomic.LoadInt32(&c.connected) == closed {
+				return
+			}
+			if c.HealthCheckFn() {
+				atomic.CompareAndSwapInt32(&c.connected, offline, online)
+				return
+			}
+			time.Sleep(time.Duration(r.Float64() * float64(c.HealthCheckInterval)))
+		}
+	}()
+}
+
+func NewClient(url *url.URL, newCustomTransport func() *http.Transport, newAuthToken func(audience string) string) *Client {
+	return &Client{
+		connected:           online,
+		MaxErrResponseSize:  4096,
+		HealthCheckInterval: 200 * time.Millisecond,
+		HealthCheckTimeout:  time.Second,
+		httpClient:          &http.Client{Transport: newCustomTransport()},
+		url:                 url,
+		newAuthToken:        newAuthToken,
+	}
+}
+
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelReadCloser) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
@@ -12,6 +12,7 @@ require (
 	github.com/gorilla/handlers v1.4.2
 	github.com/gorilla/mux v1.7.4
 	github.com/gorilla/rpc v1.2.0
+	github.com/ipfs/go-cid v0.0.5
 	github.com/ipfs/go-ipfs-api v0.1.0
 	github.com/json-iterator/go v1.1.10
 	github.com/klauspost/compress v1.10.10
@@ -20,6 +21,7 @@ require (
 	github.com/mattn/go-isatty v0.0.12
 	github.com/mitchellh/go-homedir v1.1.0
 	github.com/montanaflynn/stats v0.6.3
+	github.com/multiformats/go-multihash v0.0.13
 	github.com/ncw/directio v1.0.5
 	github.com/rjeczalik/notify v0.9.2
 	github.com/secure-io/sio-go v0.3.1
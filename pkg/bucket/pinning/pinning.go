@@ -0,0 +1,42 @@
+package pinning
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+const (
+	StatusQueued   = "queued"
+	StatusPinning  = "pinning"
+	StatusPinned   = "pinned"
+	StatusFailed   = "failed"
+	StatusUnpinned = "unpinned"
+)
+
+var ErrEmptyConfig = errors.New("pinning configuration must enable local or remote pinning")
+
+type Config struct {
+	Local  bool `json:"local"`
+	Remote bool `json:"remote"`
+}
+
+func ParseConfig(r io.Reader) (*Config, error) {
+	var config Config
+	if err := json.NewDecoder(r).Decode(&config); err != nil {
+		return nil, err
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+func (config Config) Validate() error {
+	if !config.Local && !config.Remote {
+		return ErrEmptyConfig
+	}
+	return nil
+}
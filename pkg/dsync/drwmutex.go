@@ -26,13 +26,19 @@ func log(msg ...interface{}) {
 const DRWMutexAcquireTimeout = 1 * time.Second
 const drwMutexInfinite = time.Duration(1<<63 - 1)
 
+var DRWMutexRefreshInterval = 10 * time.Second
+
 type DRWMutex struct {
 	Names        []string
 	writeLocks   []string
 	readersLocks [][]string
+	writeRefresh chan struct{}
+	readRefresh  []chan struct{}
 	m            sync.Mutex
 	clnt         *Dsync
 	ctx          context.Context
+	lockCtx      context.Context
+	lockCancel   context.CancelFunc
 }
 
 type Granted struct {
@@ -57,6 +63,15 @@ func NewDRWMutex(ctx context.Context, clnt *Dsync, names ...string) *DRWMutex {
 	}
 }
 
+func (dm *DRWMutex) Context() context.Context {
+	dm.m.Lock()
+	defer dm.m.Unlock()
+	if dm.lockCtx == nil {
+		return dm.ctx
+	}
+	return dm.lockCtx
+}
+
 func (dm *DRWMutex) Lock(id, source string) {
 
 	isReadLock := false
@@ -100,11 +115,16 @@ func (dm *DRWMutex) lockBlocking(timeout time.Duration, id, source string, isRea
 		if success {
 			dm.m.Lock()
 
+			if dm.lockCtx == nil {
+				dm.lockCtx, dm.lockCancel = context.WithCancel(dm.ctx)
+			}
 			if isReadLock {
 				dm.readersLocks = append(dm.readersLocks, make([]string, len(restClnts)))
 				copy(dm.readersLocks[len(dm.readersLocks)-1], locks[:])
+				dm.readRefresh = append(dm.readRefresh, dm.startRefresh(id, source, isReadLock))
 			} else {
 				copy(dm.writeLocks, locks[:])
+				dm.writeRefresh = dm.startRefresh(id, source, isReadLock)
 			}
 
 			dm.m.Unlock()
@@ -172,8 +192,8 @@ func lock(ds *Dsync, locks *[]string, id, source string, isReadLock bool, lockNa
 		done := false
 		timeout := time.After(DRWMutexAcquireTimeout)
 
-		dquorumReads := (len(restClnts) + 1) / 2
-		dquorum := dquorumReads + 1
+		dquorumReads := lockQuorum(len(restClnts), true)
+		dquorum := lockQuorum(len(restClnts), false)
 
 		for ; i < len(restClnts); i++ {
 
@@ -220,6 +240,79 @@ func lock(ds *Dsync, locks *[]string, id, source string, isReadLock bool, lockNa
 	return quorum
 }
 
+func (dm *DRWMutex) startRefresh(id, source string, isReadLock bool) chan struct{} {
+	stopCh := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(DRWMutexRefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if !refresh(dm.clnt, id, source, isReadLock, dm.Names...) {
+					log("Unable to refresh lock with quorum", dm.Names)
+					dm.m.Lock()
+					if dm.lockCancel != nil {
+						dm.lockCancel()
+					}
+					dm.m.Unlock()
+					return
+				}
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+	return stopCh
+}
+
+func refresh(ds *Dsync, id, source string, isReadLock bool, lockNames ...string) bool {
+	restClnts := ds.GetLockersFn()
+
+	var wg sync.WaitGroup
+	refreshed := make([]bool, len(restClnts))
+	for index, c := range restClnts {
+		if c == nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func(index int, c NetLocker) {
+			defer wg.Done()
+
+			args := LockArgs{
+				UID:       id,
+				Resources: lockNames,
+				Source:    source,
+			}
+
+			ok, err := c.Refresh(args)
+			if err != nil {
+				log("Unable to call Refresh", err)
+			}
+			refreshed[index] = ok
+		}(index, c)
+	}
+	wg.Wait()
+
+	count := 0
+	for _, ok := range refreshed {
+		if ok {
+			count++
+		}
+	}
+
+	return count >= lockQuorum(len(restClnts), isReadLock)
+}
+
+func lockQuorum(lockers int, isReadLock bool) int {
+	quorumReads := (lockers + 1) / 2
+	if isReadLock {
+		return quorumReads
+	}
+	return quorumReads + 1
+}
+
 func quorumMet(locks *[]string, isReadLock bool, quorum, quorumReads int) bool {
 
 	count := 0
@@ -269,6 +362,12 @@ func (dm *DRWMutex) Unlock() {
 
 		copy(locks, dm.writeLocks[:])
 		dm.writeLocks = make([]string, len(restClnts))
+
+		if dm.writeRefresh != nil {
+			close(dm.writeRefresh)
+			dm.writeRefresh = nil
+		}
+		dm.releaseLockCtx()
 	}
 
 	isReadLock := false
@@ -287,12 +386,25 @@ func (dm *DRWMutex) RUnlock() {
 		}
 		copy(locks, dm.readersLocks[0][:])
 		dm.readersLocks = dm.readersLocks[1:]
+
+		close(dm.readRefresh[0])
+		dm.readRefresh = dm.readRefresh[1:]
+		if len(dm.readersLocks) == 0 {
+			dm.releaseLockCtx()
+		}
 	}
 
 	isReadLock := true
 	unlock(dm.clnt, locks, isReadLock, restClnts, dm.Names...)
 }
 
+func (dm *DRWMutex) releaseLockCtx() {
+	if dm.lockCancel != nil {
+		dm.lockCancel()
+	}
+	dm.lockCtx, dm.lockCancel = nil, nil
+}
+
 func unlock(ds *Dsync, locks []string, isReadLock bool, restClnts []NetLocker, names ...string) {
 
 	for index, c := range restClnts {
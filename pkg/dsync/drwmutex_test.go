@@ -0,0 +1,148 @@
+package dsync
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testLocker struct {
+	mu        sync.Mutex
+	refreshOK bool
+	refreshes int
+}
+
+func (l *testLocker) RLock(args LockArgs) (bool, error)   { return true, nil }
+func (l *testLocker) Lock(args LockArgs) (bool, error)    { return true, nil }
+func (l *testLocker) RUnlock(args LockArgs) (bool, error) { return true, nil }
+func (l *testLocker) Unlock(args LockArgs) (bool, error)  { return true, nil }
+func (l *testLocker) Expired(args LockArgs) (bool, error) { return false, nil }
+func (l *testLocker) String() string                      { return "test" }
+func (l *testLocker) Close() error                        { return nil }
+func (l *testLocker) IsOnline() bool                      { return true }
+
+func (l *testLocker) Refresh(args LockArgs) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refreshes++
+	return l.refreshOK, nil
+}
+
+func (l *testLocker) refreshCount() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.refreshes
+}
+
+func TestLockQuorum(t *testing.T) {
+	testCases := []struct {
+		lockers    int
+		isReadLock bool
+		quorum     int
+	}{
+		{1, true, 1},
+		{1, false, 2},
+		{2, true, 1},
+		{2, false, 2},
+		{4, true, 2},
+		{4, false, 3},
+		{5, true, 3},
+		{5, false, 4},
+		{16, true, 8},
+		{16, false, 9},
+	}
+
+	for i, testCase := range testCases {
+		if quorum := lockQuorum(testCase.lockers, testCase.isReadLock); quorum != testCase.quorum {
+			t.Errorf("Test %d: expected quorum %d, got %d", i+1, testCase.quorum, quorum)
+		}
+	}
+}
+
+func TestDRWMutexRefreshLoss(t *testing.T) {
+	defer func(interval time.Duration) {
+		DRWMutexRefreshInterval = interval
+	}(DRWMutexRefreshInterval)
+	DRWMutexRefreshInterval = 10 * time.Millisecond
+
+	testCases := []struct {
+		refreshOK  []bool
+		isReadLock bool
+		lost       bool
+	}{
+		// Write locks need the same quorum to refresh as to acquire.
+		{[]bool{true, true, true, true}, false, false},
+		{[]bool{true, true, true, false}, false, false},
+		{[]bool{true, true, false, false}, false, true},
+		// Read locks keep their lower quorum.
+		{[]bool{true, true, false, false}, true, false},
+		{[]bool{true, false, false, false}, true, true},
+	}
+
+	for i, testCase := range testCases {
+		lockers := make([]NetLocker, len(testCase.refreshOK))
+		for j, ok := range testCase.refreshOK {
+			lockers[j] = &testLocker{refreshOK: ok}
+		}
+		ds := &Dsync{GetLockersFn: func() []NetLocker { return lockers }}
+
+		dm := NewDRWMutex(context.Background(), ds, "bucket/object")
+		var locked bool
+		if testCase.isReadLock {
+			locked = dm.GetRLock("id", "source", time.Second)
+		} else {
+			locked = dm.GetLock("id", "source", time.Second)
+		}
+		if !locked {
+			t.Fatalf("Test %d: unable to acquire lock", i+1)
+		}
+
+		lockCtx := dm.Context()
+		select {
+		case <-lockCtx.Done():
+			if !testCase.lost {
+				t.Errorf("Test %d: lock context cancelled while refreshes succeed", i+1)
+			}
+		case <-time.After(20 * DRWMutexRefreshInterval):
+			if testCase.lost {
+				t.Errorf("Test %d: lock context not cancelled after losing quorum", i+1)
+			}
+		}
+
+		if testCase.isReadLock {
+			dm.RUnlock()
+		} else {
+			dm.Unlock()
+		}
+		if lockCtx.Err() == nil {
+			t.Errorf("Test %d: lock context still active after unlock", i+1)
+		}
+		if dm.Context().Err() != nil {
+			t.Errorf("Test %d: mutex context cancelled after unlock", i+1)
+		}
+	}
+}
+
+func TestDRWMutexRefreshStopsAfterLoss(t *testing.T) {
+	defer func(interval time.Duration) {
+		DRWMutexRefreshInterval = interval
+	}(DRWMutexRefreshInterval)
+	DRWMutexRefreshInterval = 10 * time.Millisecond
+
+	locker := &testLocker{}
+	ds := &Dsync{GetLockersFn: func() []NetLocker { return []NetLocker{locker, locker} }}
+
+	dm := NewDRWMutex(context.Background(), ds, "bucket/object")
+	if !dm.GetLock("id", "source", time.Second) {
+		t.Fatal("unable to acquire lock")
+	}
+	defer dm.Unlock()
+
+	<-dm.Context().Done()
+	refreshes := locker.refreshCount()
+	time.Sleep(10 * DRWMutexRefreshInterval)
+	if locker.refreshCount() != refreshes {
+		t.Fatalf("expected refreshes to stop after losing the lock, got %d more", locker.refreshCount()-refreshes)
+	}
+}
@@ -17,6 +17,8 @@ type NetLocker interface {
 
 	Unlock(args LockArgs) (bool, error)
 
+	Refresh(args LockArgs) (bool, error)
+
 	Expired(args LockArgs) (bool, error)
 
 	String() string
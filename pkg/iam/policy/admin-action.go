@@ -18,6 +18,8 @@ const (
 	ConsoleLogAdminAction          = "admin:ConsoleLog"
 	KMSKeyStatusAdminAction        = "admin:KMSKeyStatus"
 	ServerInfoAdminAction          = "admin:ServerInfo"
+	ServiceRestartAdminAction      = "admin:ServiceRestart"
+	ServiceStopAdminAction         = "admin:ServiceStop"
 	OBDInfoAdminAction             = "admin:OBDInfo"
 
 	ServerUpdateAdminAction = "admin:ServerUpdate"
@@ -44,13 +46,19 @@ const (
 	GetPolicyAdminAction        = "admin:GetPolicy"
 	AttachPolicyAdminAction     = "admin:AttachUserOrGroupPolicy"
 	ListUserPoliciesAdminAction = "admin:ListUserPolicies"
-	AllAdminActions             = "admin:*"
+
+	SetBucketPinningAdminAction = "admin:SetBucketPinning"
+	GetBucketPinningAdminAction = "admin:GetBucketPinning"
+
+	AllAdminActions = "admin:*"
 )
 
 var supportedAdminActions = map[AdminAction]struct{}{
 	AllAdminActions:                {},
 	HealAdminAction:                {},
 	ServerInfoAdminAction:          {},
+	ServiceRestartAdminAction:      {},
+	ServiceStopAdminAction:         {},
 	StorageInfoAdminAction:         {},
 	DataUsageInfoAdminAction:       {},
 	TopLocksAdminAction:            {},
@@ -77,6 +85,8 @@ var supportedAdminActions = map[AdminAction]struct{}{
 	GetPolicyAdminAction:           {},
 	AttachPolicyAdminAction:        {},
 	ListUserPoliciesAdminAction:    {},
+	SetBucketPinningAdminAction:    {},
+	GetBucketPinningAdminAction:    {},
 }
 
 func parseAdminAction(s string) (AdminAction, error) {
@@ -98,6 +108,8 @@ var adminActionConditionKeyMap = map[Action]condition.KeySet{
 	HealAdminAction:                condition.NewKeySet(condition.AllSupportedAdminKeys...),
 	StorageInfoAdminAction:         condition.NewKeySet(condition.AllSupportedAdminKeys...),
 	ServerInfoAdminAction:          condition.NewKeySet(condition.AllSupportedAdminKeys...),
+	ServiceRestartAdminAction:      condition.NewKeySet(condition.AllSupportedAdminKeys...),
+	ServiceStopAdminAction:         condition.NewKeySet(condition.AllSupportedAdminKeys...),
 	DataUsageInfoAdminAction:       condition.NewKeySet(condition.AllSupportedAdminKeys...),
 	OBDInfoAdminAction:             condition.NewKeySet(condition.AllSupportedAdminKeys...),
 	TopLocksAdminAction:            condition.NewKeySet(condition.AllSupportedAdminKeys...),
@@ -123,4 +135,6 @@ var adminActionConditionKeyMap = map[Action]condition.KeySet{
 	GetPolicyAdminAction:           condition.NewKeySet(condition.AllSupportedAdminKeys...),
 	AttachPolicyAdminAction:        condition.NewKeySet(condition.AllSupportedAdminKeys...),
 	ListUserPoliciesAdminAction:    condition.NewKeySet(condition.AllSupportedAdminKeys...),
+	SetBucketPinningAdminAction:    condition.NewKeySet(condition.AllSupportedAdminKeys...),
+	GetBucketPinningAdminAction:    condition.NewKeySet(condition.AllSupportedAdminKeys...),
 }